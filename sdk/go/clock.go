@@ -0,0 +1,79 @@
+package prismer
+
+import (
+	"sync"
+	"time"
+)
+
+// clock abstracts time.Now and time.After so backoff, expiry and sync
+// timing can be tested deterministically. realClock is the default
+// everywhere; tests inject a testClock through the owning type's
+// unexported setter.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock delegates to the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// formatRFC3339 renders a timestamp the way the API expects.
+func formatRFC3339(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// testClock is a manually advanced clock. After channels fire when
+// Advance moves the clock past their deadline.
+type testClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []testClockWaiter
+}
+
+type testClockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newTestClock(start time.Time) *testClock {
+	return &testClock{now: start}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	at := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, testClockWaiter{at: at, ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward, firing every waiter whose deadline has
+// passed.
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.at.After(c.now) {
+			w.ch <- c.now
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	c.waiters = remaining
+}
@@ -0,0 +1,46 @@
+package prismer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTestClockAdvanceFiresWaiters(t *testing.T) {
+	clk := newTestClock(time.Unix(0, 0))
+	ch := clk.After(10 * time.Second)
+
+	clk.Advance(5 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("waiter fired before its deadline")
+	default:
+	}
+
+	clk.Advance(5 * time.Second)
+	select {
+	case <-ch:
+	default:
+		t.Fatal("waiter did not fire at its deadline")
+	}
+}
+
+func TestOfflineManagerUsesInjectedClock(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := newOfflineManager(&fakeDoer{}, nil)
+	m.setClock(newTestClock(start))
+	m.SetOnline(false)
+
+	res, err := m.Dispatch(context.Background(), "POST", "/api/im/direct/u1/messages", nil,
+		map[string]any{"content": "hi"})
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	var msg StoredMessage
+	if err := res.Decode(&msg); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if msg.CreatedAt != formatRFC3339(start) {
+		t.Fatalf("CreatedAt = %q, want %q", msg.CreatedAt, formatRFC3339(start))
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login <username>",
+	Short: "Authenticate as an agent and store its token",
+	Long: `Authenticate as an agent and store its token.
+
+When a token for the same username is already stored it is refreshed in
+place; otherwise the username is registered and the new token persisted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.Auth.IMToken != "" && cfg.Auth.IMUsername == args[0] {
+			statusf("already logged in as %s, refreshing token", args[0])
+			return refreshStoredToken(cmd, cfg)
+		}
+
+		client := prismer.NewClient("", clientOptions(cfg)...)
+		statusf("logging in as %s ...", args[0])
+		res, err := client.IM().Account.Register(cmd.Context(), &prismer.IMRegisterOptions{
+			Username: args[0],
+		})
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var reg prismer.IMRegisterResult
+		if err := res.Decode(&reg); err != nil {
+			return err
+		}
+		cfg.Auth.IMToken = reg.Token
+		cfg.Auth.IMUserID = reg.UserID
+		cfg.Auth.IMUsername = reg.Username
+		if reg.ExpiresIn > 0 {
+			expires := time.Now().Add(time.Duration(reg.ExpiresIn) * time.Second)
+			cfg.Auth.IMTokenExpires = expires.UTC().Format(time.RFC3339)
+		}
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+		statusf("logged in and stored token")
+		emitData("%s", reg.UserID)
+		return nil
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Clear the stored authentication state",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.Auth.IMToken == "" {
+			statusf("not logged in")
+			return nil
+		}
+		cfg.Auth.IMToken = ""
+		cfg.Auth.IMUserID = ""
+		cfg.Auth.IMUsername = ""
+		cfg.Auth.IMTokenExpires = ""
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+		statusf("logged out")
+		return nil
+	},
+}
+
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Exchange the stored token for a fresh one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.Auth.IMToken == "" {
+			return fmt.Errorf("not logged in; run 'prismer login <username>' first")
+		}
+		return refreshStoredToken(cmd, cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd, logoutCmd, refreshCmd)
+}
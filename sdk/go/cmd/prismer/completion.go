@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+// completionTimeout bounds the API round-trip behind a dynamic completion
+// so a slow server never hangs the user's shell.
+const completionTimeout = 3 * time.Second
+
+// completeContactIDs completes the first positional argument with the IDs
+// of the account's contacts, described by their usernames.
+func completeContactIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	client, err := getIMClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), completionTimeout)
+	defer cancel()
+	res, err := client.IM().Contacts.List(ctx, nil)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var users []prismer.IMUser
+	if res.Err() != nil || res.Decode(&users) != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	completions := make([]string, 0, len(users))
+	for _, u := range users {
+		completions = append(completions, u.ID+"\t"+u.Username)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGroupIDs completes the first positional argument with the IDs of
+// the account's group conversations, described by their titles.
+func completeGroupIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	client, err := getIMClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), completionTimeout)
+	defer cancel()
+	res, err := client.IM().Groups.List(ctx, nil)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var groups []prismer.IMConversation
+	if res.Err() != nil || res.Decode(&groups) != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	completions := make([]string, 0, len(groups))
+	for _, g := range groups {
+		completions = append(completions, g.ID+"\t"+g.Title)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	// Cobra generates the `completion bash|zsh|fish|powershell` command on
+	// its own; here we only attach dynamic completion for IM arguments.
+	imSendCmd.ValidArgsFunction = completeContactIDs
+	imMessagesCmd.ValidArgsFunction = completeContactIDs
+	imAgentCardCmd.ValidArgsFunction = completeContactIDs
+	imGroupsGetCmd.ValidArgsFunction = completeGroupIDs
+	imGroupsSendCmd.ValidArgsFunction = completeGroupIDs
+	imGroupsMessagesCmd.ValidArgsFunction = completeGroupIDs
+	imGroupsAddMemberCmd.ValidArgsFunction = completeGroupIDs
+	imGroupsRemoveMemberCmd.ValidArgsFunction = completeGroupIDs
+	imGroupsLeaveCmd.ValidArgsFunction = completeGroupIDs
+}
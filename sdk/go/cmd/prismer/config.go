@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+// Config is the on-disk CLI configuration, stored as TOML at
+// ~/.prismer/config.toml.
+type Config struct {
+	Default struct {
+		BaseURL     string `toml:"base_url,omitempty"`
+		Environment string `toml:"environment,omitempty"`
+		APIKey      string `toml:"api_key,omitempty"`
+	} `toml:"default"`
+	Auth struct {
+		IMToken        string `toml:"im_token,omitempty"`
+		IMUserID       string `toml:"im_user_id,omitempty"`
+		IMUsername     string `toml:"im_username,omitempty"`
+		IMTokenExpires string `toml:"im_token_expires,omitempty"`
+		// RefreshWindow is how close to expiry the CLI refreshes the IM
+		// token on its own, as a Go duration string. Defaults to 1h.
+		RefreshWindow string `toml:"refresh_window,omitempty"`
+	} `toml:"auth"`
+}
+
+// configPath returns the location of the CLI config file.
+func configPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".prismer", "config.toml"), nil
+}
+
+// loadConfig reads the config file, returning an empty config when the
+// file does not exist yet.
+func loadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// saveConfig writes the config file, creating ~/.prismer when needed.
+func saveConfig(cfg *Config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return toml.NewEncoder(f).Encode(cfg)
+}
+
+// setConfigValue resolves a dotted key like "default.base_url" and sets it.
+func setConfigValue(cfg *Config, key, value string) error {
+	switch key {
+	case "default.base_url":
+		cfg.Default.BaseURL = value
+	case "default.environment":
+		cfg.Default.Environment = value
+	case "default.api_key":
+		cfg.Default.APIKey = value
+	case "auth.im_token":
+		cfg.Auth.IMToken = value
+	case "auth.im_user_id":
+		cfg.Auth.IMUserID = value
+	case "auth.im_username":
+		cfg.Auth.IMUsername = value
+	case "auth.im_token_expires":
+		cfg.Auth.IMTokenExpires = value
+	case "auth.refresh_window":
+		cfg.Auth.RefreshWindow = value
+	default:
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+	return nil
+}
+
+// getConfigValue resolves a dotted key like "default.base_url" and returns
+// its current value, which is empty when the field is unset.
+func getConfigValue(cfg *Config, key string) (string, error) {
+	switch key {
+	case "default.base_url":
+		return cfg.Default.BaseURL, nil
+	case "default.environment":
+		return cfg.Default.Environment, nil
+	case "default.api_key":
+		return cfg.Default.APIKey, nil
+	case "auth.im_token":
+		return cfg.Auth.IMToken, nil
+	case "auth.im_user_id":
+		return cfg.Auth.IMUserID, nil
+	case "auth.im_username":
+		return cfg.Auth.IMUsername, nil
+	case "auth.im_token_expires":
+		return cfg.Auth.IMTokenExpires, nil
+	case "auth.refresh_window":
+		return cfg.Auth.RefreshWindow, nil
+	default:
+		return "", fmt.Errorf("unknown config key: %s", key)
+	}
+}
+
+// Global connection flags, registered on rootCmd. Each overrides the
+// matching PRISMER_* environment variable, which in turn overrides the
+// config file.
+var (
+	flagBaseURL     string
+	flagEnvironment string
+	flagAPIKey      string
+	flagToken       string
+)
+
+// resolve returns the first non-empty of flag value, environment variable
+// and config value, implementing the flag > env > file precedence.
+func resolve(flagValue, envName, cfgValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv(envName); env != "" {
+		return env
+	}
+	return cfgValue
+}
+
+// clientOptions converts the config, environment and global flags into
+// client options. At each precedence level an explicit base URL beats a
+// named environment.
+func clientOptions(cfg *Config) []prismer.ClientOption {
+	var opts []prismer.ClientOption
+	switch {
+	case flagBaseURL != "":
+		opts = append(opts, prismer.WithBaseURL(flagBaseURL))
+	case flagEnvironment != "":
+		opts = append(opts, prismer.WithEnvironment(prismer.Environment(flagEnvironment)))
+	case os.Getenv("PRISMER_BASE_URL") != "":
+		opts = append(opts, prismer.WithBaseURL(os.Getenv("PRISMER_BASE_URL")))
+	case os.Getenv("PRISMER_ENVIRONMENT") != "":
+		opts = append(opts, prismer.WithEnvironment(prismer.Environment(os.Getenv("PRISMER_ENVIRONMENT"))))
+	case cfg.Default.BaseURL != "":
+		opts = append(opts, prismer.WithBaseURL(cfg.Default.BaseURL))
+	case cfg.Default.Environment != "":
+		opts = append(opts, prismer.WithEnvironment(prismer.Environment(cfg.Default.Environment)))
+	}
+	return opts
+}
+
+// getAPIClient builds a client authenticated with the platform API key,
+// used by the context and parse commands.
+func getAPIClient() (*prismer.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	key := resolve(flagAPIKey, "PRISMER_API_KEY", cfg.Default.APIKey)
+	if key == "" {
+		return nil, errors.New("no API key configured; run 'prismer config set default.api_key <key>' or set PRISMER_API_KEY")
+	}
+	client := prismer.NewClient(key, clientOptions(cfg)...)
+	debugf("base URL: %s", client.BaseURL())
+	return client, nil
+}
+
+// getIMClient builds a client authenticated with the stored IM token, used
+// by the im commands.
+func getIMClient() (*prismer.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	token := resolve(flagToken, "PRISMER_IM_TOKEN", cfg.Auth.IMToken)
+	if token == "" {
+		return nil, errors.New("not registered; run 'prismer im register <username>' first")
+	}
+	client := prismer.NewClient(token, clientOptions(cfg)...)
+	debugf("base URL: %s", client.BaseURL())
+	return client, nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and write the CLI configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the full configuration as TOML",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		return toml.NewEncoder(outW).Encode(cfg)
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print one configuration value by dotted key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		value, err := getConfigValue(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		emitData("%s", value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set one configuration value by dotted key",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := setConfigValue(cfg, args[0], args[1]); err != nil {
+			return err
+		}
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+		statusf("set %s", args[0])
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd, configGetCmd, configSetCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Save, load and search the context cache",
+}
+
+var (
+	contextSaveVisibility string
+	contextSaveJSON       bool
+)
+
+var contextSaveCmd = &cobra.Command{
+	Use:   "save <url> <hqcc>",
+	Short: "Save one piece of content to the context cache",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getAPIClient()
+		if err != nil {
+			return err
+		}
+		statusf("saving %s ...", args[0])
+		res, err := client.Save(cmd.Context(), &prismer.SaveOptions{
+			URL:        args[0],
+			HQCC:       args[1],
+			Visibility: contextSaveVisibility,
+		})
+		if err != nil {
+			return err
+		}
+		if contextSaveJSON {
+			return emitJSON(res)
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		statusf("saved")
+		return nil
+	},
+}
+
+var (
+	contextSaveBatchDryRun bool
+	contextSaveBatchJSON   bool
+)
+
+// saveBatchSize is how many items the CLI submits per SaveBatch call,
+// matching the server-side batch limit.
+const saveBatchSize = 50
+
+var contextSaveBatchCmd = &cobra.Command{
+	Use:   "save-batch <file.json>",
+	Short: "Save many items from a JSON file, in batches of 50",
+	Long: `Save many items from a JSON file, in batches of 50.
+
+The file holds an array of objects with the same shape as a single save:
+{"url": "...", "hqcc": "...", "raw": "...", "visibility": "...", "meta": {...}}.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		var items []prismer.SaveOptions
+		if err := json.Unmarshal(data, &items); err != nil {
+			return fmt.Errorf("%s is not a JSON array of save items: %w", args[0], err)
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("%s contains no items", args[0])
+		}
+		for i, item := range items {
+			if item.URL == "" {
+				return fmt.Errorf("item %d has no url", i)
+			}
+			if item.HQCC == "" && item.Raw == "" {
+				return fmt.Errorf("item %d (%s) has neither hqcc nor raw content", i, item.URL)
+			}
+		}
+		if contextSaveBatchDryRun {
+			statusf("%d items valid; would send %d batch(es)", len(items), (len(items)+saveBatchSize-1)/saveBatchSize)
+			return nil
+		}
+
+		client, err := getAPIClient()
+		if err != nil {
+			return err
+		}
+		summary := prismer.SaveSummary{}
+		var failed []prismer.SaveItemResult
+		for start := 0; start < len(items); start += saveBatchSize {
+			end := start + saveBatchSize
+			if end > len(items) {
+				end = len(items)
+			}
+			statusf("saving items %d-%d of %d ...", start+1, end, len(items))
+			res, err := client.SaveBatch(cmd.Context(), &prismer.SaveBatchOptions{Items: items[start:end]})
+			if err != nil {
+				return err
+			}
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.Summary != nil {
+				summary.Saved += res.Summary.Saved
+				summary.Skipped += res.Summary.Skipped
+				summary.Failed += res.Summary.Failed
+			}
+			failed = append(failed, res.FailedItems()...)
+		}
+
+		if contextSaveBatchJSON {
+			return emitJSON(map[string]any{"summary": summary, "failed": failed})
+		}
+		for _, item := range failed {
+			if item.Error != nil {
+				emitData("failed\t%s\t%s", item.URL, item.Error.Code)
+				continue
+			}
+			emitData("failed\t%s", item.URL)
+		}
+		statusf("saved %d, skipped %d, failed %d", summary.Saved, summary.Skipped, summary.Failed)
+		return nil
+	},
+}
+
+var (
+	contextLoadLimit int
+	contextLoadJSON  bool
+)
+
+var contextLoadCmd = &cobra.Command{
+	Use:   "load <url> [url...]",
+	Short: "Load cached content by URL",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getAPIClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.Load(cmd.Context(), &prismer.LoadOptions{URLs: args, Limit: contextLoadLimit})
+		if err != nil {
+			return err
+		}
+		if contextLoadJSON {
+			return emitJSON(res)
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		for _, item := range res.Items {
+			switch item.Status() {
+			case prismer.LoadStatusError:
+				emitData("[error: %s]\t%s", item.Error.Code, item.URL)
+			case prismer.LoadStatusNotFound:
+				emitData("[not found]\t%s", item.URL)
+			default:
+				emitData("[%s]\t%s", item.Status(), item.URL)
+				emitData("%s", item.Content)
+			}
+		}
+		return nil
+	},
+}
+
+var (
+	contextSearchLimit       int
+	contextSearchRanking     string
+	contextSearchListPresets bool
+	contextSearchJSON        bool
+)
+
+var contextSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the context cache by text",
+	Args:  cobra.RangeArgs(0, 1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getAPIClient()
+		if err != nil {
+			return err
+		}
+		if contextSearchListPresets {
+			presets, err := client.RankingPresets(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if contextSearchJSON {
+				return emitJSON(presets)
+			}
+			for _, p := range presets {
+				emitData("%s\t%s", p.Name, p.Description)
+			}
+			return nil
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("a query argument is required")
+		}
+		opts := &prismer.SearchOptions{Query: args[0], Limit: contextSearchLimit}
+		if contextSearchRanking != "" {
+			presets, err := client.RankingPresets(cmd.Context())
+			if err != nil {
+				return err
+			}
+			known := false
+			names := make([]string, 0, len(presets))
+			for _, p := range presets {
+				names = append(names, p.Name)
+				if p.Name == contextSearchRanking {
+					known = true
+				}
+			}
+			if !known {
+				return fmt.Errorf("unknown ranking preset %q; available: %s", contextSearchRanking, strings.Join(names, ", "))
+			}
+			opts.Ranking = &prismer.RankingConfig{Preset: contextSearchRanking}
+		}
+		res, err := client.Search(cmd.Context(), opts)
+		if err != nil {
+			return err
+		}
+		if contextSearchJSON {
+			return emitJSON(res)
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		for _, item := range res.Items {
+			if item.Status() == prismer.LoadStatusError {
+				emitData("%.3f\t[error: %s]\t%s", item.Score, item.Error.Code, item.URL)
+				continue
+			}
+			emitData("%.3f\t[%s]\t%s", item.Score, item.Status(), item.URL)
+		}
+		return nil
+	},
+}
+
+func init() {
+	contextSaveCmd.Flags().StringVar(&contextSaveVisibility, "visibility", "", "content visibility: private or public")
+	contextSaveCmd.Flags().BoolVar(&contextSaveJSON, "json", false, "print the raw response as JSON")
+	contextSaveBatchCmd.Flags().BoolVar(&contextSaveBatchDryRun, "dry-run", false, "validate the file without sending anything")
+	contextSaveBatchCmd.Flags().BoolVar(&contextSaveBatchJSON, "json", false, "print the aggregated result as JSON")
+	contextLoadCmd.Flags().IntVar(&contextLoadLimit, "limit", 0, "maximum items to return")
+	contextLoadCmd.Flags().BoolVar(&contextLoadJSON, "json", false, "print the raw response as JSON")
+	contextSearchCmd.Flags().IntVar(&contextSearchLimit, "limit", 10, "maximum results to return")
+	contextSearchCmd.Flags().StringVar(&contextSearchRanking, "ranking", "", "ranking preset name (validated against the server)")
+	contextSearchCmd.Flags().BoolVar(&contextSearchListPresets, "list-presets", false, "list available ranking presets and exit")
+	contextSearchCmd.Flags().BoolVar(&contextSearchJSON, "json", false, "print the raw response as JSON")
+
+	contextCmd.AddCommand(contextSaveCmd, contextSaveBatchCmd, contextLoadCmd, contextSearchCmd)
+	rootCmd.AddCommand(contextCmd)
+}
@@ -0,0 +1,731 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+var imCmd = &cobra.Command{
+	Use:   "im",
+	Short: "Agent-to-agent messaging",
+}
+
+var (
+	imRegisterDisplayName  string
+	imRegisterType         string
+	imRegisterCapabilities []string
+)
+
+var imRegisterCmd = &cobra.Command{
+	Use:   "register <username>",
+	Short: "Register an agent account and store its token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		client := prismer.NewClient("", clientOptions(cfg)...)
+		statusf("registering %s ...", args[0])
+		res, err := client.IM().Account.Register(cmd.Context(), &prismer.IMRegisterOptions{
+			Username:     args[0],
+			DisplayName:  imRegisterDisplayName,
+			Type:         imRegisterType,
+			Capabilities: imRegisterCapabilities,
+		})
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var reg prismer.IMRegisterResult
+		if err := res.Decode(&reg); err != nil {
+			return err
+		}
+		cfg.Auth.IMToken = reg.Token
+		cfg.Auth.IMUserID = reg.UserID
+		cfg.Auth.IMUsername = reg.Username
+		if reg.ExpiresIn > 0 {
+			expires := time.Now().Add(time.Duration(reg.ExpiresIn) * time.Second)
+			cfg.Auth.IMTokenExpires = expires.UTC().Format(time.RFC3339)
+		}
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+		statusf("registered and stored token")
+		emitData("%s", reg.UserID)
+		return nil
+	},
+}
+
+// defaultRefreshWindow is how close to expiry the CLI refreshes the IM
+// token proactively; override with auth.refresh_window.
+const defaultRefreshWindow = time.Hour
+
+// refreshStoredToken exchanges the stored IM token for a fresh one and
+// persists it to the config.
+func refreshStoredToken(cmd *cobra.Command, cfg *Config) error {
+	client := prismer.NewClient(cfg.Auth.IMToken, clientOptions(cfg)...)
+	res, err := client.IM().Account.RefreshToken(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if err := res.Err(); err != nil {
+		return err
+	}
+	var reg prismer.IMRegisterResult
+	if err := res.Decode(&reg); err != nil {
+		return err
+	}
+	cfg.Auth.IMToken = reg.Token
+	if reg.ExpiresIn > 0 {
+		expires := time.Now().Add(time.Duration(reg.ExpiresIn) * time.Second)
+		cfg.Auth.IMTokenExpires = expires.UTC().Format(time.RFC3339)
+	}
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+	statusf("token refreshed")
+	return nil
+}
+
+// maybeRefreshToken refreshes the stored token when it is within the
+// configured window of its expiry.
+func maybeRefreshToken(cmd *cobra.Command, cfg *Config) {
+	if cfg.Auth.IMToken == "" || cfg.Auth.IMTokenExpires == "" {
+		return
+	}
+	expires, err := time.Parse(time.RFC3339, cfg.Auth.IMTokenExpires)
+	if err != nil {
+		return
+	}
+	window := defaultRefreshWindow
+	if cfg.Auth.RefreshWindow != "" {
+		if d, err := time.ParseDuration(cfg.Auth.RefreshWindow); err == nil {
+			window = d
+		}
+	}
+	if time.Until(expires) > window {
+		return
+	}
+	debugf("token expires %s, refreshing", expires.Format(time.RFC3339))
+	if err := refreshStoredToken(cmd, cfg); err != nil {
+		statusf("token refresh failed: %v", err)
+	}
+}
+
+var imRefreshTokenCmd = &cobra.Command{
+	Use:   "refresh-token",
+	Short: "Exchange the stored IM token for a fresh one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.Auth.IMToken == "" {
+			return fmt.Errorf("not registered; run 'prismer im register <username>' first")
+		}
+		return refreshStoredToken(cmd, cfg)
+	},
+}
+
+var imStatusJSON bool
+
+var imStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the authenticated identity and token expiry",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		maybeRefreshToken(cmd, cfg)
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Account.Me(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if imStatusJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var me prismer.IMUser
+		if err := res.Decode(&me); err != nil {
+			return err
+		}
+		emitData("user\t%s (%s)", me.Username, me.ID)
+		if cfg.Auth.IMTokenExpires != "" {
+			if expires, err := time.Parse(time.RFC3339, cfg.Auth.IMTokenExpires); err == nil {
+				emitData("token\texpires %s (%s)", expires.Format(time.RFC3339), time.Until(expires).Round(time.Second))
+			}
+		}
+		return nil
+	},
+}
+
+var (
+	imSendType     string
+	imSendParentID string
+	imSendJSON     bool
+)
+
+var imSendCmd = &cobra.Command{
+	Use:   "send <user-id> <content>",
+	Short: "Send a direct message",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Direct.SendMessage(cmd.Context(), args[0], args[1], &prismer.IMSendOptions{
+			Type:     prismer.MessageType(imSendType),
+			ParentID: imSendParentID,
+		})
+		if err != nil {
+			return err
+		}
+		if imSendJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("sent")
+		return nil
+	},
+}
+
+var (
+	imMessagesLimit int
+	imMessagesJSON  bool
+)
+
+var imMessagesCmd = &cobra.Command{
+	Use:   "messages <user-id>",
+	Short: "Show the direct message history with a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Direct.GetMessages(cmd.Context(), args[0], &prismer.IMPaginationOptions{Limit: imMessagesLimit})
+		if err != nil {
+			return err
+		}
+		if imMessagesJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var msgs []prismer.IMMessage
+		if err := res.Decode(&msgs); err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			emitData("%s\t%s\t%s", m.CreatedAt, m.SenderID, m.Content)
+		}
+		return nil
+	},
+}
+
+var imMarkReadCmd = &cobra.Command{
+	Use:   "mark-read <conversation-id> <message-id>",
+	Short: "Post a read receipt for a single message",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Messages.MarkRead(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("marked read")
+		return nil
+	},
+}
+
+var imContactsJSON bool
+
+var imContactsCmd = &cobra.Command{
+	Use:   "contacts",
+	Short: "List contacts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Contacts.List(cmd.Context(), nil)
+		if err != nil {
+			return err
+		}
+		if imContactsJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var users []prismer.IMUser
+		if err := res.Decode(&users); err != nil {
+			return err
+		}
+		for _, u := range users {
+			emitData("%s\t%s\t%s", u.ID, u.Username, u.Status)
+		}
+		return nil
+	},
+}
+
+var (
+	imDiscoverType       string
+	imDiscoverCapability string
+	imDiscoverQuery      string
+	imDiscoverLimit      int
+	imDiscoverOffset     int
+	imDiscoverJSON       bool
+)
+
+var imDiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Discover public agents",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Contacts.Discover(cmd.Context(), &prismer.IMDiscoverOptions{
+			Type:       imDiscoverType,
+			Capability: imDiscoverCapability,
+			Query:      imDiscoverQuery,
+			Limit:      imDiscoverLimit,
+			Offset:     imDiscoverOffset,
+		})
+		if err != nil {
+			return err
+		}
+		if imDiscoverJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var agents []prismer.IMDiscoverAgent
+		if err := res.Decode(&agents); err != nil {
+			return err
+		}
+		for _, a := range agents {
+			caps := ""
+			if a.Card != nil {
+				caps = fmt.Sprintf("%v", a.Card.Capabilities)
+			}
+			emitData("%s\t%s\t%s\t%s", a.UserID, a.Username, a.Status, caps)
+		}
+		return nil
+	},
+}
+
+var imAgentCardJSON bool
+
+var imAgentCardCmd = &cobra.Command{
+	Use:   "agent-card <user-id>",
+	Short: "Show an agent's card (type, capabilities, status)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		card, err := client.IM().Contacts.GetAgentCard(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if imAgentCardJSON {
+			return emitJSON(card)
+		}
+		emitData("type:\t%s", card.Type)
+		emitData("status:\t%s", card.Status)
+		emitData("capabilities:\t%s", strings.Join(card.Capabilities, ", "))
+		if card.Description != "" {
+			emitData("description:\t%s", card.Description)
+		}
+		return nil
+	},
+}
+
+var (
+	imScheduleAt   string
+	imScheduleType string
+	imScheduleJSON bool
+)
+
+var imScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage scheduled messages",
+}
+
+var imScheduleCreateCmd = &cobra.Command{
+	Use:   "create <conversation-id> <content>",
+	Short: "Schedule a message for future delivery",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sendAt, err := time.Parse(time.RFC3339, imScheduleAt)
+		if err != nil {
+			return fmt.Errorf("invalid --at time (want RFC3339, e.g. 2026-09-01T09:00:00Z): %w", err)
+		}
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		scheduled, err := client.IM().Messages.Schedule(cmd.Context(), args[0], args[1], sendAt, &prismer.IMSendOptions{
+			Type: prismer.MessageType(imScheduleType),
+		})
+		if err != nil {
+			return err
+		}
+		if imScheduleJSON {
+			return emitJSON(scheduled)
+		}
+		statusf("scheduled %s for %s", scheduled.ID, scheduled.SendAt)
+		return nil
+	},
+}
+
+var imScheduleListJSON bool
+
+var imScheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pending scheduled messages",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		scheduled, err := client.IM().Messages.ListScheduled(cmd.Context(), nil)
+		if err != nil {
+			return err
+		}
+		if imScheduleListJSON {
+			return emitJSON(scheduled)
+		}
+		for _, m := range scheduled {
+			emitData("%s\t%s\t%s\t%s", m.ID, m.SendAt, m.ConversationID, m.Content)
+		}
+		return nil
+	},
+}
+
+var imScheduleCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a scheduled message before delivery",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Messages.CancelScheduled(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("cancelled")
+		return nil
+	},
+}
+
+var imGroupsCmd = &cobra.Command{
+	Use:   "groups",
+	Short: "Manage group conversations",
+}
+
+var imGroupsListJSON bool
+
+var imGroupsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List groups",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Groups.List(cmd.Context(), nil)
+		if err != nil {
+			return err
+		}
+		if imGroupsListJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var groups []prismer.IMConversation
+		if err := res.Decode(&groups); err != nil {
+			return err
+		}
+		for _, g := range groups {
+			emitData("%s\t%s", g.ID, g.Title)
+		}
+		return nil
+	},
+}
+
+var (
+	imGroupsCreateDescription string
+	imGroupsCreateMembers     []string
+	imGroupsCreateJSON        bool
+)
+
+var imGroupsCreateCmd = &cobra.Command{
+	Use:   "create <title>",
+	Short: "Create a group",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Groups.Create(cmd.Context(), &prismer.IMCreateGroupOptions{
+			Title:       args[0],
+			Description: imGroupsCreateDescription,
+			MemberIDs:   imGroupsCreateMembers,
+		})
+		if err != nil {
+			return err
+		}
+		if imGroupsCreateJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var group prismer.IMConversation
+		if err := res.Decode(&group); err != nil {
+			return err
+		}
+		statusf("created group %s", group.Title)
+		emitData("%s", group.ID)
+		return nil
+	},
+}
+
+var imGroupsGetJSON bool
+
+var imGroupsGetCmd = &cobra.Command{
+	Use:   "get <group-id>",
+	Short: "Show a group and its members",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Groups.Get(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if imGroupsGetJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var group prismer.IMConversation
+		if err := res.Decode(&group); err != nil {
+			return err
+		}
+		emitData("%s\t%s", group.ID, group.Title)
+		for _, p := range group.Participants {
+			emitData("member\t%s\t%s", p.ID, p.Username)
+		}
+		return nil
+	},
+}
+
+var imGroupsAddMemberCmd = &cobra.Command{
+	Use:   "add-member <group-id> <user-id>",
+	Short: "Add a member to a group",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Groups.AddMember(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("added %s", args[1])
+		return nil
+	},
+}
+
+var imGroupsRemoveMemberCmd = &cobra.Command{
+	Use:   "remove-member <group-id> <user-id>",
+	Short: "Remove a member from a group",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Groups.RemoveMember(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("removed %s", args[1])
+		return nil
+	},
+}
+
+var imGroupsLeaveCmd = &cobra.Command{
+	Use:   "leave <group-id>",
+	Short: "Leave a group",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Groups.Leave(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("left %s", args[0])
+		return nil
+	},
+}
+
+var imGroupsSendJSON bool
+
+var imGroupsSendCmd = &cobra.Command{
+	Use:   "send <group-id> <content>",
+	Short: "Send a message to a group",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Groups.SendMessage(cmd.Context(), args[0], args[1], nil)
+		if err != nil {
+			return err
+		}
+		if imGroupsSendJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("sent")
+		return nil
+	},
+}
+
+var (
+	imGroupsMessagesLimit int
+	imGroupsMessagesJSON  bool
+)
+
+var imGroupsMessagesCmd = &cobra.Command{
+	Use:   "messages <group-id>",
+	Short: "Show a group's message history",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Groups.GetMessages(cmd.Context(), args[0], &prismer.IMPaginationOptions{Limit: imGroupsMessagesLimit})
+		if err != nil {
+			return err
+		}
+		if imGroupsMessagesJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var msgs []prismer.IMMessage
+		if err := res.Decode(&msgs); err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			emitData("%s\t%s\t%s", m.CreatedAt, m.SenderID, m.Content)
+		}
+		return nil
+	},
+}
+
+func init() {
+	imRegisterCmd.Flags().StringVar(&imRegisterDisplayName, "display-name", "", "human-readable name")
+	imRegisterCmd.Flags().StringVar(&imRegisterType, "type", "agent", "account type")
+	imRegisterCmd.Flags().StringSliceVar(&imRegisterCapabilities, "capability", nil, "agent capability (repeatable)")
+	imStatusCmd.Flags().BoolVar(&imStatusJSON, "json", false, "print the raw response as JSON")
+	imSendCmd.Flags().StringVar(&imSendType, "type", "", "message type (text, markdown, ...)")
+	imSendCmd.Flags().StringVar(&imSendParentID, "parent", "", "parent message ID for threaded replies")
+	imSendCmd.Flags().BoolVar(&imSendJSON, "json", false, "print the raw response as JSON")
+	imMessagesCmd.Flags().IntVar(&imMessagesLimit, "limit", 20, "maximum messages to return")
+	imMessagesCmd.Flags().BoolVar(&imMessagesJSON, "json", false, "print the raw response as JSON")
+	imContactsCmd.Flags().BoolVar(&imContactsJSON, "json", false, "print the raw response as JSON")
+	imDiscoverCmd.Flags().StringVar(&imDiscoverType, "type", "", "filter by agent type")
+	imDiscoverCmd.Flags().StringVar(&imDiscoverCapability, "capability", "", "filter by capability")
+	imDiscoverCmd.Flags().StringVar(&imDiscoverQuery, "query", "", "match against usernames and display names")
+	imDiscoverCmd.Flags().IntVar(&imDiscoverLimit, "limit", 0, "max agents per page (0 = server default)")
+	imDiscoverCmd.Flags().IntVar(&imDiscoverOffset, "offset", 0, "agents to skip, for paging")
+	imDiscoverCmd.Flags().BoolVar(&imDiscoverJSON, "json", false, "print the raw response as JSON")
+	imAgentCardCmd.Flags().BoolVar(&imAgentCardJSON, "json", false, "print the raw response as JSON")
+	imGroupsListCmd.Flags().BoolVar(&imGroupsListJSON, "json", false, "print the raw response as JSON")
+	imGroupsGetCmd.Flags().BoolVar(&imGroupsGetJSON, "json", false, "print the raw response as JSON")
+	imGroupsCreateCmd.Flags().StringVar(&imGroupsCreateDescription, "description", "", "group description")
+	imGroupsCreateCmd.Flags().StringSliceVar(&imGroupsCreateMembers, "member", nil, "initial member user ID (repeatable)")
+	imGroupsCreateCmd.Flags().BoolVar(&imGroupsCreateJSON, "json", false, "print the raw response as JSON")
+	imGroupsSendCmd.Flags().BoolVar(&imGroupsSendJSON, "json", false, "print the raw response as JSON")
+	imGroupsMessagesCmd.Flags().IntVar(&imGroupsMessagesLimit, "limit", 20, "maximum messages to return")
+	imGroupsMessagesCmd.Flags().BoolVar(&imGroupsMessagesJSON, "json", false, "print the raw response as JSON")
+
+	imScheduleCreateCmd.Flags().StringVar(&imScheduleAt, "at", "", "delivery time, RFC3339 (required)")
+	_ = imScheduleCreateCmd.MarkFlagRequired("at")
+	imScheduleCreateCmd.Flags().StringVar(&imScheduleType, "type", "", "message type (text, markdown, ...)")
+	imScheduleCreateCmd.Flags().BoolVar(&imScheduleJSON, "json", false, "print the raw response as JSON")
+	imScheduleListCmd.Flags().BoolVar(&imScheduleListJSON, "json", false, "print the raw response as JSON")
+
+	imGroupsCmd.AddCommand(imGroupsListCmd, imGroupsGetCmd, imGroupsCreateCmd, imGroupsSendCmd, imGroupsMessagesCmd, imGroupsAddMemberCmd, imGroupsRemoveMemberCmd, imGroupsLeaveCmd)
+	imScheduleCmd.AddCommand(imScheduleCreateCmd, imScheduleListCmd, imScheduleCancelCmd)
+	imCmd.AddCommand(imRegisterCmd, imRefreshTokenCmd, imStatusCmd, imSendCmd, imMessagesCmd, imMarkReadCmd, imContactsCmd, imDiscoverCmd, imAgentCardCmd, imGroupsCmd, imScheduleCmd)
+	rootCmd.AddCommand(imCmd)
+}
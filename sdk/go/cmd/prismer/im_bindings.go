@@ -0,0 +1,142 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+var imBindingsCmd = &cobra.Command{
+	Use:   "bindings",
+	Short: "Link the account to external platforms (Telegram, Discord, ...)",
+}
+
+var (
+	imBindingsCreatePlatform string
+	imBindingsCreateBotToken string
+	imBindingsCreateJSON     bool
+)
+
+var imBindingsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Start a new platform binding",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Bindings.Create(cmd.Context(), &prismer.IMBindingOptions{
+			Platform: imBindingsCreatePlatform,
+			BotToken: imBindingsCreateBotToken,
+		})
+		if err != nil {
+			return err
+		}
+		if imBindingsCreateJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var binding prismer.IMBinding
+		if err := res.Decode(&binding); err != nil {
+			return err
+		}
+		statusf("created %s binding %s; verify it with 'prismer im bindings verify %s <code>'",
+			binding.Platform, binding.ID, binding.ID)
+		emitData("%s", binding.ID)
+		return nil
+	},
+}
+
+var imBindingsVerifyJSON bool
+
+var imBindingsVerifyCmd = &cobra.Command{
+	Use:   "verify <binding-id> <code>",
+	Short: "Confirm a binding with the code delivered on the platform",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Bindings.Verify(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if imBindingsVerifyJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("verified")
+		return nil
+	},
+}
+
+var imBindingsListJSON bool
+
+var imBindingsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the account's bindings",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Bindings.List(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if imBindingsListJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var bindings []prismer.IMBinding
+		if err := res.Decode(&bindings); err != nil {
+			return err
+		}
+		for _, b := range bindings {
+			emitData("%s\t%s\t%s", b.ID, b.Platform, b.Status)
+		}
+		return nil
+	},
+}
+
+var imBindingsDeleteCmd = &cobra.Command{
+	Use:   "delete <binding-id>",
+	Short: "Remove a binding",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Bindings.Delete(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("deleted")
+		return nil
+	},
+}
+
+func init() {
+	imBindingsCreateCmd.Flags().StringVar(&imBindingsCreatePlatform, "platform", "", "platform name, e.g. telegram or discord (required)")
+	imBindingsCreateCmd.Flags().StringVar(&imBindingsCreateBotToken, "bot-token", "", "platform bot token")
+	imBindingsCreateCmd.Flags().BoolVar(&imBindingsCreateJSON, "json", false, "print the raw response as JSON")
+	_ = imBindingsCreateCmd.MarkFlagRequired("platform")
+	imBindingsVerifyCmd.Flags().BoolVar(&imBindingsVerifyJSON, "json", false, "print the raw response as JSON")
+	imBindingsListCmd.Flags().BoolVar(&imBindingsListJSON, "json", false, "print the raw response as JSON")
+
+	imBindingsCmd.AddCommand(imBindingsCreateCmd, imBindingsVerifyCmd, imBindingsListCmd, imBindingsDeleteCmd)
+	imCmd.AddCommand(imBindingsCmd)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+var (
+	imForwardTo          string
+	imForwardFrom        string
+	imForwardAttribution string
+	imForwardJSON        bool
+)
+
+var imForwardCmd = &cobra.Command{
+	Use:   "forward <message-id>",
+	Short: "Forward a message into another conversation",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		var opts *prismer.ForwardOptions
+		if imForwardAttribution != "" {
+			opts = &prismer.ForwardOptions{Attribution: imForwardAttribution}
+		}
+		res, err := client.IM().Messages.Forward(cmd.Context(), imForwardFrom, args[0], imForwardTo, opts)
+		if err != nil {
+			return err
+		}
+		if imForwardJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("forwarded to %s", imForwardTo)
+		return nil
+	},
+}
+
+func init() {
+	imForwardCmd.Flags().StringVar(&imForwardTo, "to", "", "target conversation ID (required)")
+	imForwardCmd.Flags().StringVar(&imForwardFrom, "from", "", "source conversation ID")
+	imForwardCmd.Flags().StringVar(&imForwardAttribution, "attribution", "", "attribution prefix, e.g. 'Forwarded from #research'")
+	imForwardCmd.Flags().BoolVar(&imForwardJSON, "json", false, "print the raw response as JSON")
+	_ = imForwardCmd.MarkFlagRequired("to")
+	imCmd.AddCommand(imForwardCmd)
+}
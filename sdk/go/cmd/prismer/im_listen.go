@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+var (
+	imListenConversations []string
+	imListenTransport     string
+	imListenJSON          bool
+)
+
+var imListenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Tail realtime events until interrupted",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if imListenTransport != "ws" && imListenTransport != "sse" {
+			return fmt.Errorf("unknown transport %q; use ws or sse", imListenTransport)
+		}
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		onMessage := func(p *prismer.MessageNewPayload) {
+			emitData("message\t%s\t%s\t%s", p.ConversationID, p.Message.SenderID, p.Message.Content)
+		}
+		onTyping := func(p *prismer.TypingPayload) {
+			state := "stopped typing"
+			if p.IsTyping {
+				state = "typing"
+			}
+			emitData("typing\t%s\t%s\t%s", p.ConversationID, p.UserID, state)
+		}
+		onPresence := func(p *prismer.PresencePayload) {
+			emitData("presence\t%s\t%s", p.UserID, p.Status)
+		}
+		onAny := func(eventType string, payload json.RawMessage) {
+			out, _ := json.Marshal(map[string]any{"type": eventType, "payload": payload})
+			emitData("%s", out)
+		}
+
+		switch imListenTransport {
+		case "ws":
+			ws, err := client.IM().Realtime.ConnectWS(ctx, &prismer.RealtimeConfig{AutoReconnect: true})
+			if err != nil {
+				return err
+			}
+			defer ws.Disconnect()
+			if imListenJSON {
+				ws.OnAny(onAny)
+			} else {
+				ws.OnMessageNew(onMessage)
+				ws.OnTyping(onTyping)
+				ws.OnPresence(onPresence)
+			}
+			for _, id := range imListenConversations {
+				if err := ws.JoinConversation(ctx, id); err != nil {
+					return fmt.Errorf("failed to join %s: %w", id, err)
+				}
+			}
+		case "sse":
+			sse, err := client.IM().Realtime.ConnectSSE(ctx, &prismer.RealtimeConfig{AutoReconnect: true})
+			if err != nil {
+				return err
+			}
+			defer sse.Disconnect()
+			if imListenJSON {
+				sse.OnAny(onAny)
+			} else {
+				sse.OnMessageNew(onMessage)
+				sse.OnTyping(onTyping)
+				sse.OnPresence(onPresence)
+			}
+			for _, id := range imListenConversations {
+				if err := sse.JoinConversation(ctx, id); err != nil {
+					return fmt.Errorf("failed to join %s: %w", id, err)
+				}
+			}
+		}
+
+		statusf("listening (%s); press Ctrl-C to stop", imListenTransport)
+		<-ctx.Done()
+		statusf("disconnecting")
+		return nil
+	},
+}
+
+func init() {
+	imListenCmd.Flags().StringSliceVar(&imListenConversations, "conversation", nil, "conversation ID to join (repeatable)")
+	imListenCmd.Flags().StringVar(&imListenTransport, "transport", "ws", "realtime transport: ws or sse")
+	imListenCmd.Flags().BoolVar(&imListenJSON, "json", false, "print raw events as JSON lines")
+	imCmd.AddCommand(imListenCmd)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	imRawBody  string
+	imRawQuery []string
+)
+
+var imRawCmd = &cobra.Command{
+	Use:   "raw <method> <path>",
+	Short: "Issue an arbitrary authenticated IM request (advanced)",
+	Long: `Issue an arbitrary authenticated request against the configured deployment
+and print the raw response. This is an advanced, unstable escape hatch for
+endpoints the SDK has no typed wrapper for yet; paths and payloads are sent
+as-is and may break without notice.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method := strings.ToUpper(args[0])
+		path := args[1]
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("path must start with '/': %s", path)
+		}
+
+		var body any
+		if imRawBody != "" {
+			raw := []byte(imRawBody)
+			if strings.HasPrefix(imRawBody, "@") {
+				var err error
+				raw, err = os.ReadFile(imRawBody[1:])
+				if err != nil {
+					return err
+				}
+			}
+			if err := json.Unmarshal(raw, &body); err != nil {
+				return fmt.Errorf("body is not valid JSON: %w", err)
+			}
+		}
+
+		query := url.Values{}
+		for _, kv := range imRawQuery {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return fmt.Errorf("query must be key=value: %s", kv)
+			}
+			query.Add(k, v)
+		}
+
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		statusf("%s %s", method, path)
+		raw, err := client.Do(cmd.Context(), method, path, query, body)
+		if err != nil {
+			return err
+		}
+		emitData("%s", raw)
+		return nil
+	},
+}
+
+func init() {
+	imRawCmd.Flags().StringVar(&imRawBody, "body", "", "JSON request body, or @file to read it from disk")
+	imRawCmd.Flags().StringArrayVar(&imRawQuery, "query", nil, "query parameter as key=value (repeatable)")
+	imCmd.AddCommand(imRawCmd)
+}
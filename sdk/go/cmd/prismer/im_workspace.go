@@ -0,0 +1,193 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+var imWorkspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Wire IM conversations to Prismer workspaces",
+}
+
+var (
+	imWorkspaceInitTitle string
+	imWorkspaceInitAgent string
+	imWorkspaceInitJSON  bool
+)
+
+var imWorkspaceInitCmd = &cobra.Command{
+	Use:   "init <workspace-id>",
+	Short: "Create the direct conversation backing a workspace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Workspace.Init(cmd.Context(), &prismer.IMWorkspaceInitOptions{
+			WorkspaceID: args[0],
+			Title:       imWorkspaceInitTitle,
+			AgentID:     imWorkspaceInitAgent,
+		})
+		if err != nil {
+			return err
+		}
+		if imWorkspaceInitJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var conv prismer.IMConversation
+		if err := res.Decode(&conv); err != nil {
+			return err
+		}
+		statusf("workspace conversation ready")
+		emitData("%s", conv.ID)
+		return nil
+	},
+}
+
+var (
+	imWorkspaceInitGroupTitle  string
+	imWorkspaceInitGroupAgents []string
+	imWorkspaceInitGroupJSON   bool
+)
+
+var imWorkspaceInitGroupCmd = &cobra.Command{
+	Use:   "init-group <workspace-id>",
+	Short: "Create the multi-agent group conversation backing a workspace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Workspace.InitGroup(cmd.Context(), &prismer.IMWorkspaceInitGroupOptions{
+			WorkspaceID: args[0],
+			Title:       imWorkspaceInitGroupTitle,
+			AgentIDs:    imWorkspaceInitGroupAgents,
+		})
+		if err != nil {
+			return err
+		}
+		if imWorkspaceInitGroupJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var conv prismer.IMConversation
+		if err := res.Decode(&conv); err != nil {
+			return err
+		}
+		statusf("workspace group ready")
+		emitData("%s", conv.ID)
+		return nil
+	},
+}
+
+var imWorkspaceAddAgentCmd = &cobra.Command{
+	Use:   "add-agent <workspace-id> <agent-id>",
+	Short: "Attach an agent to the workspace conversation",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Workspace.AddAgent(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		statusf("added agent %s", args[1])
+		return nil
+	},
+}
+
+var imWorkspaceListAgentsJSON bool
+
+var imWorkspaceListAgentsCmd = &cobra.Command{
+	Use:   "list-agents <workspace-id>",
+	Short: "List the agents attached to a workspace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.IM().Workspace.ListAgents(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if imWorkspaceListAgentsJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var agents []prismer.IMUser
+		if err := res.Decode(&agents); err != nil {
+			return err
+		}
+		for _, a := range agents {
+			emitData("%s\t%s\t%s", a.ID, a.Username, a.Status)
+		}
+		return nil
+	},
+}
+
+var imWorkspaceMentionsJSON bool
+
+var imWorkspaceMentionsCmd = &cobra.Command{
+	Use:   "mentions <conversation-id> [query]",
+	Short: "List mentionable participants for a conversation",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getIMClient()
+		if err != nil {
+			return err
+		}
+		query := ""
+		if len(args) > 1 {
+			query = args[1]
+		}
+		res, err := client.IM().Workspace.MentionAutocomplete(cmd.Context(), args[0], query)
+		if err != nil {
+			return err
+		}
+		if imWorkspaceMentionsJSON {
+			return emitJSON(res)
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		var users []prismer.IMUser
+		if err := res.Decode(&users); err != nil {
+			return err
+		}
+		for _, u := range users {
+			emitData("%s\t%s", u.ID, u.Username)
+		}
+		return nil
+	},
+}
+
+func init() {
+	imWorkspaceInitCmd.Flags().StringVar(&imWorkspaceInitTitle, "title", "", "conversation title")
+	imWorkspaceInitCmd.Flags().StringVar(&imWorkspaceInitAgent, "agent", "", "agent user ID to seed the conversation with")
+	imWorkspaceInitCmd.Flags().BoolVar(&imWorkspaceInitJSON, "json", false, "print the raw response as JSON")
+	imWorkspaceInitGroupCmd.Flags().StringVar(&imWorkspaceInitGroupTitle, "title", "", "group title")
+	imWorkspaceInitGroupCmd.Flags().StringSliceVar(&imWorkspaceInitGroupAgents, "agent", nil, "agent user ID (repeatable)")
+	imWorkspaceInitGroupCmd.Flags().BoolVar(&imWorkspaceInitGroupJSON, "json", false, "print the raw response as JSON")
+	imWorkspaceListAgentsCmd.Flags().BoolVar(&imWorkspaceListAgentsJSON, "json", false, "print the raw response as JSON")
+	imWorkspaceMentionsCmd.Flags().BoolVar(&imWorkspaceMentionsJSON, "json", false, "print the raw response as JSON")
+
+	imWorkspaceCmd.AddCommand(imWorkspaceInitCmd, imWorkspaceInitGroupCmd, imWorkspaceAddAgentCmd, imWorkspaceListAgentsCmd, imWorkspaceMentionsCmd)
+	imCmd.AddCommand(imWorkspaceCmd)
+}
@@ -0,0 +1,42 @@
+// Command prismer is the command-line interface for the Prismer.AI
+// platform: context cache management, document parsing and IM messaging.
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var commandStart time.Time
+
+var rootCmd = &cobra.Command{
+	Use:          "prismer",
+	Short:        "Command-line interface for the Prismer.AI platform",
+	SilenceUsage: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		commandStart = time.Now()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		debugf("%s took %s", cmd.CommandPath(), time.Since(commandStart).Round(time.Millisecond))
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&flagQuiet, "quiet", "q", false, "suppress status output; only print data and errors")
+	rootCmd.PersistentFlags().BoolVarP(&flagVerbose, "verbose", "v", false, "print diagnostic details (base URL, timing)")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+
+	rootCmd.PersistentFlags().StringVar(&flagBaseURL, "base-url", "", "API base URL (overrides config and PRISMER_BASE_URL)")
+	rootCmd.PersistentFlags().StringVar(&flagEnvironment, "environment", "", "named environment (overrides config and PRISMER_ENVIRONMENT)")
+	rootCmd.PersistentFlags().StringVar(&flagAPIKey, "api-key", "", "platform API key (overrides config and PRISMER_API_KEY)")
+	rootCmd.PersistentFlags().StringVar(&flagToken, "token", "", "IM token (overrides config and PRISMER_IM_TOKEN)")
+	rootCmd.MarkFlagsMutuallyExclusive("base-url", "environment")
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// The CLI keeps a strict split between data and diagnostics so output can
+// be piped: results go to outW (stdout), status and progress lines go to
+// errW (stderr). Commands must not write to os.Stdout/os.Stderr directly.
+var (
+	outW io.Writer = os.Stdout
+	errW io.Writer = os.Stderr
+)
+
+// Global output-control flags, registered on rootCmd.
+var (
+	flagQuiet   bool
+	flagVerbose bool
+)
+
+// emitData writes a machine-consumable result line to stdout.
+func emitData(format string, args ...any) {
+	fmt.Fprintf(outW, format+"\n", args...)
+}
+
+// emitJSON writes v as indented JSON to stdout.
+func emitJSON(v any) error {
+	enc := json.NewEncoder(outW)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// statusf writes a human status or progress line to stderr so it never
+// pollutes piped data. Suppressed by --quiet.
+func statusf(format string, args ...any) {
+	if flagQuiet {
+		return
+	}
+	fmt.Fprintf(errW, format+"\n", args...)
+}
+
+// debugf writes a diagnostic line to stderr, only with --verbose.
+func debugf(format string, args ...any) {
+	if !flagVerbose {
+		return
+	}
+	fmt.Fprintf(errW, "debug: "+format+"\n", args...)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	prismer "github.com/Prismer-AI/Prismer/sdk/go"
+)
+
+var parseCmd = &cobra.Command{
+	Use:   "parse",
+	Short: "Parse documents into markdown",
+}
+
+var (
+	parseRunMode     string
+	parseRunOutput   string
+	parseRunWait     bool
+	parseRunInterval time.Duration
+	parseRunTimeout  time.Duration
+	parseRunJSON     bool
+)
+
+var parseRunCmd = &cobra.Command{
+	Use:   "run <url>",
+	Short: "Submit a document for parsing",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getAPIClient()
+		if err != nil {
+			return err
+		}
+		statusf("submitting %s ...", args[0])
+		res, err := client.ParsePDF(cmd.Context(), &prismer.ParseOptions{
+			URL:    args[0],
+			Mode:   parseRunMode,
+			Output: parseRunOutput,
+		})
+		if err != nil {
+			return err
+		}
+		if res.TaskID != "" && res.Markdown == "" {
+			if !parseRunWait {
+				if parseRunJSON {
+					return emitJSON(res)
+				}
+				statusf("task accepted; poll with: prismer parse status %s", res.TaskID)
+				emitData("%s", res.TaskID)
+				return nil
+			}
+			statusf("task %s accepted; waiting ...", res.TaskID)
+			res, err = client.WaitForParseTask(cmd.Context(), res.TaskID, &prismer.PollOptions{
+				Interval: parseRunInterval,
+				Timeout:  parseRunTimeout,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if parseRunJSON {
+			return emitJSON(res)
+		}
+		emitData("%s", res.Markdown)
+		return nil
+	},
+}
+
+var (
+	parseWaitInterval time.Duration
+	parseWaitTimeout  time.Duration
+	parseWaitOutput   string
+	parseWaitJSON     bool
+)
+
+var parseWaitCmd = &cobra.Command{
+	Use:   "wait <task-id>",
+	Short: "Block until an async parse task completes and print the document",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getAPIClient()
+		if err != nil {
+			return err
+		}
+		statusf("waiting for task %s ...", args[0])
+		res, err := client.WaitForParseTask(cmd.Context(), args[0], &prismer.PollOptions{
+			Interval: parseWaitInterval,
+			Timeout:  parseWaitTimeout,
+		})
+		if err != nil {
+			return err
+		}
+		if parseWaitJSON {
+			return emitJSON(res)
+		}
+		if parseWaitOutput != "" {
+			if err := os.WriteFile(parseWaitOutput, []byte(res.Markdown), 0o644); err != nil {
+				return err
+			}
+			statusf("wrote %s", parseWaitOutput)
+			return nil
+		}
+		emitData("%s", res.Markdown)
+		return nil
+	},
+}
+
+var parseStatusJSON bool
+
+var parseStatusCmd = &cobra.Command{
+	Use:   "status <task-id>",
+	Short: "Show the status of an async parse task",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getAPIClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.ParseStatus(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if parseStatusJSON {
+			return emitJSON(res)
+		}
+		emitData("%s", res.Status)
+		return nil
+	},
+}
+
+var parseResultJSON bool
+
+var parseResultCmd = &cobra.Command{
+	Use:   "result <task-id>",
+	Short: "Fetch the document of a completed parse task",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getAPIClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.ParseResultByID(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if parseResultJSON {
+			return emitJSON(res)
+		}
+		emitData("%s", res.Markdown)
+		return nil
+	},
+}
+
+func init() {
+	parseRunCmd.Flags().StringVar(&parseRunMode, "mode", "async", "parse mode: sync or async")
+	parseRunCmd.Flags().StringVar(&parseRunOutput, "output", "", "output format: markdown or json")
+	parseRunCmd.Flags().BoolVar(&parseRunWait, "wait", false, "poll the task to completion instead of printing the task ID")
+	parseRunCmd.Flags().DurationVar(&parseRunInterval, "interval", 0, "poll interval when waiting (default 2s)")
+	parseRunCmd.Flags().DurationVar(&parseRunTimeout, "timeout", 0, "maximum time to wait (default 5m)")
+	parseRunCmd.Flags().BoolVar(&parseRunJSON, "json", false, "print the raw response as JSON")
+	parseStatusCmd.Flags().BoolVar(&parseStatusJSON, "json", false, "print the raw response as JSON")
+	parseResultCmd.Flags().BoolVar(&parseResultJSON, "json", false, "print the raw response as JSON")
+	parseWaitCmd.Flags().DurationVar(&parseWaitInterval, "interval", 0, "poll interval (default 2s)")
+	parseWaitCmd.Flags().DurationVar(&parseWaitTimeout, "timeout", 0, "maximum time to wait (default 5m)")
+	parseWaitCmd.Flags().StringVar(&parseWaitOutput, "output", "", "write the markdown to this file instead of stdout")
+	parseWaitCmd.Flags().BoolVar(&parseWaitJSON, "json", false, "print the raw response as JSON")
+
+	parseCmd.AddCommand(parseRunCmd, parseWaitCmd, parseStatusCmd, parseResultCmd)
+	rootCmd.AddCommand(parseCmd)
+}
@@ -0,0 +1,490 @@
+package prismer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxSaveBatch is the server-side limit on items per SaveBatch call.
+const maxSaveBatch = 50
+
+// SaveOptions describes one piece of content to store in the context cache.
+type SaveOptions struct {
+	URL        string         `json:"url"`
+	HQCC       string         `json:"hqcc,omitempty"`
+	Raw        string         `json:"raw,omitempty"`
+	Visibility string         `json:"visibility,omitempty"`
+	Meta       map[string]any `json:"meta,omitempty"`
+}
+
+// SaveBatchOptions carries up to 50 items for a single batch save.
+type SaveBatchOptions struct {
+	Items []SaveOptions `json:"items"`
+}
+
+// SaveSummary aggregates the outcome of a (batch) save.
+type SaveSummary struct {
+	Saved   int `json:"saved"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// SaveItemResult is the per-item outcome of a batch save.
+type SaveItemResult struct {
+	URL    string    `json:"url"`
+	Status string    `json:"status,omitempty"`
+	Error  *APIError `json:"error,omitempty"`
+}
+
+// SaveResult is the response of the save endpoints.
+type SaveResult struct {
+	Success bool             `json:"success"`
+	Summary *SaveSummary     `json:"summary,omitempty"`
+	Results []SaveItemResult `json:"results,omitempty"`
+	Error   *APIError        `json:"error,omitempty"`
+}
+
+// RankingConfig tunes search result ranking.
+type RankingConfig struct {
+	Preset  string             `json:"preset,omitempty"`
+	Weights map[string]float64 `json:"weights,omitempty"`
+}
+
+// RankingPreset is a server-defined weighted ranking configuration.
+type RankingPreset struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Weights     map[string]float64 `json:"weights,omitempty"`
+}
+
+// LoadItem pairs a URL with pre-fetched raw content, for pages the server
+// cannot crawl on its own (logins, paywalls): the server processes the
+// supplied content instead of fetching the URL.
+type LoadItem struct {
+	URL string `json:"url"`
+	Raw string `json:"raw,omitempty"`
+}
+
+// LoadOptions selects content by URL. Headers are forwarded with the
+// server's fetch of uncached URLs, e.g. cookies or auth for pages that
+// require them; Items supplies pre-fetched content the server should
+// process instead of fetching itself.
+type LoadOptions struct {
+	URLs    []string          `json:"urls,omitempty"`
+	Items   []LoadItem        `json:"items,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Limit   int               `json:"limit,omitempty"`
+}
+
+// SearchOptions queries the context cache by text.
+type SearchOptions struct {
+	Query   string         `json:"query"`
+	Limit   int            `json:"limit,omitempty"`
+	Ranking *RankingConfig `json:"ranking,omitempty"`
+}
+
+// LoadResultItem is one entry in a load or search response. Found reports
+// whether the URL was present in the cache.
+type LoadResultItem struct {
+	URL     string         `json:"url"`
+	Found   bool           `json:"found"`
+	Content string         `json:"content,omitempty"`
+	Score   float64        `json:"score,omitempty"`
+	Meta    map[string]any `json:"meta,omitempty"`
+	Error   *APIError      `json:"error,omitempty"`
+}
+
+// LoadResult is the response of the load and search endpoints.
+type LoadResult struct {
+	Success bool             `json:"success"`
+	Items   []LoadResultItem `json:"items,omitempty"`
+	Error   *APIError        `json:"error,omitempty"`
+}
+
+// Load item statuses, as reported by LoadResultItem.Status.
+const (
+	LoadStatusCached   = "cached"
+	LoadStatusFetched  = "fetched"
+	LoadStatusNotFound = "not found"
+	LoadStatusError    = "error"
+)
+
+// Status classifies the item: errored, absent from the cache, served from
+// the cache, or freshly processed (the server marks cache hits with a
+// cached flag in Meta).
+func (i *LoadResultItem) Status() string {
+	switch {
+	case i.Error != nil:
+		return LoadStatusError
+	case !i.Found:
+		return LoadStatusNotFound
+	case i.Meta != nil && i.Meta["cached"] == true:
+		return LoadStatusCached
+	default:
+		return LoadStatusFetched
+	}
+}
+
+// FoundItems returns the items that were present (cached or fetched).
+func (r *LoadResult) FoundItems() []LoadResultItem {
+	return r.filterItems(func(i *LoadResultItem) bool { return i.Error == nil && i.Found })
+}
+
+// MissingItems returns the items absent from the cache.
+func (r *LoadResult) MissingItems() []LoadResultItem {
+	return r.filterItems(func(i *LoadResultItem) bool { return i.Error == nil && !i.Found })
+}
+
+// ErroredItems returns the items that failed.
+func (r *LoadResult) ErroredItems() []LoadResultItem {
+	return r.filterItems(func(i *LoadResultItem) bool { return i.Error != nil })
+}
+
+func (r *LoadResult) filterItems(keep func(*LoadResultItem) bool) []LoadResultItem {
+	out := make([]LoadResultItem, 0, len(r.Items))
+	for i := range r.Items {
+		if keep(&r.Items[i]) {
+			out = append(out, r.Items[i])
+		}
+	}
+	return out
+}
+
+// Save stores one piece of content in the context cache.
+func (c *Client) Save(ctx context.Context, opts *SaveOptions) (*SaveResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/context/save", nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[SaveResult](raw)
+}
+
+// SaveBatch stores up to 50 items in one call. Results is always
+// populated with one entry per input item, in input order, so callers can
+// tell exactly which items failed and retry just those.
+func (c *Client) SaveBatch(ctx context.Context, opts *SaveBatchOptions) (*SaveResult, error) {
+	if len(opts.Items) > maxSaveBatch {
+		return nil, fmt.Errorf("%w: batch exceeds %d items", ErrBatchTooLarge, maxSaveBatch)
+	}
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/context/save/batch", nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	res, err := decodeJSON[SaveResult](raw)
+	if err != nil {
+		return nil, err
+	}
+	alignBatchResults(opts.Items, res)
+	return res, nil
+}
+
+// alignBatchResults reorders a batch response's per-item results to match
+// the input items, synthesizing an entry for any item the server left
+// out so Results[i] always corresponds to Items[i].
+func alignBatchResults(items []SaveOptions, res *SaveResult) {
+	byURL := make(map[string]SaveItemResult, len(res.Results))
+	for _, r := range res.Results {
+		byURL[r.URL] = r
+	}
+	aligned := make([]SaveItemResult, len(items))
+	for i, item := range items {
+		if r, ok := byURL[item.URL]; ok {
+			aligned[i] = r
+		} else {
+			aligned[i] = SaveItemResult{URL: item.URL}
+		}
+	}
+	res.Results = aligned
+}
+
+// FailedItems returns the per-item results that carry an error or a
+// "failed" status.
+func (r *SaveResult) FailedItems() []SaveItemResult {
+	var out []SaveItemResult
+	for _, item := range r.Results {
+		if item.Error != nil || item.Status == "failed" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// SaveBatchWithRetry performs a batch save and resubmits only the items
+// that failed, up to maxAttempts total attempts. The returned result is
+// aligned to opts.Items with each item's final outcome; its Summary is
+// recomputed from those outcomes. Useful when ingesting a large crawl
+// where a few URLs fail transiently.
+func (c *Client) SaveBatchWithRetry(ctx context.Context, opts *SaveBatchOptions, maxAttempts int) (*SaveResult, error) {
+	res, err := c.SaveBatch(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 1; attempt < maxAttempts; attempt++ {
+		var retryIdx []int
+		for i, item := range res.Results {
+			if item.Error != nil || item.Status == "failed" {
+				retryIdx = append(retryIdx, i)
+			}
+		}
+		if len(retryIdx) == 0 {
+			break
+		}
+		retry := &SaveBatchOptions{Items: make([]SaveOptions, len(retryIdx))}
+		for j, i := range retryIdx {
+			retry.Items[j] = opts.Items[i]
+		}
+		rr, err := c.SaveBatch(ctx, retry)
+		if err != nil {
+			return res, err
+		}
+		for j, i := range retryIdx {
+			res.Results[i] = rr.Results[j]
+		}
+	}
+
+	summary := &SaveSummary{}
+	for _, item := range res.Results {
+		switch {
+		case item.Error != nil || item.Status == "failed":
+			summary.Failed++
+		case item.Status == "skipped":
+			summary.Skipped++
+		default:
+			summary.Saved++
+		}
+	}
+	res.Summary = summary
+	res.Success = summary.Failed == 0
+	return res, nil
+}
+
+// Load retrieves cached content for the given URLs.
+func (c *Client) Load(ctx context.Context, opts *LoadOptions) (*LoadResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/context/load", nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[LoadResult](raw)
+}
+
+// LoadByHash retrieves cached content by its SHA-256, matching either the
+// raw or HQCC hash. It complements URL-based Load for deduplication and
+// verification flows where the same content may live under several URLs.
+func (c *Client) LoadByHash(ctx context.Context, sha256 string) (*LoadResultItem, error) {
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/context/load/hash", nil, map[string]any{"sha256": sha256})
+	if err != nil {
+		return nil, err
+	}
+	res, err := decodeJSON[LoadResult](raw)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	for i := range res.Items {
+		if res.Items[i].Found {
+			return &res.Items[i], nil
+		}
+	}
+	return nil, ErrContentNotFound
+}
+
+// ListContextOptions pages through the account's cached entries.
+type ListContextOptions struct {
+	Limit  int `json:"limit,omitempty"`
+	Offset int `json:"offset,omitempty"`
+}
+
+// ListContext enumerates what is cached under the account, for auditing
+// credit/storage use and finding stale entries worth pruning.
+func (c *Client) ListContext(ctx context.Context, opts *ListContextOptions) (*LoadResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/context/list", nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[LoadResult](raw)
+}
+
+// DeleteContext removes one cached URL from the context cache.
+func (c *Client) DeleteContext(ctx context.Context, url string) (*SaveResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/context/delete", nil, map[string]any{"url": url})
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[SaveResult](raw)
+}
+
+// DeleteContextBatch removes up to 50 cached URLs in one call.
+func (c *Client) DeleteContextBatch(ctx context.Context, urls []string) (*SaveResult, error) {
+	if len(urls) > maxSaveBatch {
+		return nil, fmt.Errorf("%w: batch exceeds %d items", ErrBatchTooLarge, maxSaveBatch)
+	}
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/context/delete/batch", nil, map[string]any{"urls": urls})
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[SaveResult](raw)
+}
+
+// RankingPresets returns the ranking presets the server supports, so
+// callers can validate RankingConfig.Preset instead of guessing.
+func (c *Client) RankingPresets(ctx context.Context) ([]RankingPreset, error) {
+	raw, err := c.doRequest(ctx, http.MethodGet, "/api/context/ranking/presets", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := decodeJSON[struct {
+		Success bool            `json:"success"`
+		Presets []RankingPreset `json:"presets"`
+		Error   *APIError       `json:"error,omitempty"`
+	}](raw)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Success && res.Error != nil {
+		return nil, res.Error
+	}
+	return res.Presets, nil
+}
+
+const (
+	defaultCrawlBatchSize   = 20
+	defaultCrawlConcurrency = 4
+)
+
+// CrawlOptions configures a resumable bulk load. Checkpoint, when set,
+// receives one completed URL per line; feeding that file back through
+// ReadCrawlCheckpoint as Completed on a restart skips finished work.
+type CrawlOptions struct {
+	// BatchSize is how many URLs go into each Load call. Defaults to 20.
+	BatchSize int
+
+	// Concurrency bounds how many Load calls run at once. Defaults to 4.
+	Concurrency int
+
+	// Checkpoint, when non-nil, is appended with each URL that reached a
+	// terminal outcome. URLs whose batch failed in transport are not
+	// checkpointed, so a restart retries them.
+	Checkpoint io.Writer
+
+	// Completed lists URLs to skip, typically read from a prior
+	// checkpoint with ReadCrawlCheckpoint.
+	Completed map[string]bool
+}
+
+// ReadCrawlCheckpoint parses a checkpoint written by LoadCrawl back into
+// the set CrawlOptions.Completed expects.
+func ReadCrawlCheckpoint(r io.Reader) (map[string]bool, error) {
+	done := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			done[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// LoadCrawl loads many URLs in checkpointed batches with bounded
+// concurrency, streaming results as they complete. The channel is closed
+// once every URL has been processed or ctx is cancelled; a transport
+// failure surfaces as items whose Error code is NETWORK rather than
+// aborting the crawl.
+func (c *Client) LoadCrawl(ctx context.Context, urls []string, opts *CrawlOptions) (<-chan LoadResultItem, error) {
+	o := CrawlOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = defaultCrawlBatchSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultCrawlConcurrency
+	}
+
+	pending := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if !o.Completed[u] {
+			pending = append(pending, u)
+		}
+	}
+
+	batches := make(chan []string)
+	out := make(chan LoadResultItem)
+	var checkpointMu sync.Mutex
+	checkpoint := func(url string) {
+		if o.Checkpoint == nil {
+			return
+		}
+		checkpointMu.Lock()
+		defer checkpointMu.Unlock()
+		fmt.Fprintln(o.Checkpoint, url)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				res, err := c.Load(ctx, &LoadOptions{URLs: batch})
+				if err != nil {
+					for _, u := range batch {
+						item := LoadResultItem{URL: u, Error: &APIError{Code: "NETWORK", Message: err.Error()}}
+						select {
+						case out <- item:
+						case <-ctx.Done():
+							return
+						}
+					}
+					continue
+				}
+				for _, item := range res.Items {
+					checkpoint(item.URL)
+					select {
+					case out <- item:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(batches)
+		for start := 0; start < len(pending); start += o.BatchSize {
+			end := start + o.BatchSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			select {
+			case batches <- pending[start:end]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+// Search queries the context cache by text with optional ranking.
+func (c *Client) Search(ctx context.Context, opts *SearchOptions) (*LoadResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/context/search", nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[LoadResult](raw)
+}
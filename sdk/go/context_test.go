@@ -0,0 +1,105 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadCrawlCheckpointsAndSkipsCompleted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var opts LoadOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			t.Errorf("decode load request: %v", err)
+		}
+		items := make([]LoadResultItem, 0, len(opts.URLs))
+		for _, u := range opts.URLs {
+			items = append(items, LoadResultItem{URL: u, Found: true, Content: "c:" + u})
+		}
+		_ = json.NewEncoder(w).Encode(LoadResult{Success: true, Items: items})
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	var checkpoint strings.Builder
+	urls := []string{"https://a", "https://b", "https://c"}
+	results, err := client.LoadCrawl(context.Background(), urls, &CrawlOptions{
+		BatchSize:   2,
+		Concurrency: 1,
+		Checkpoint:  &checkpoint,
+		Completed:   map[string]bool{"https://b": true},
+	})
+	if err != nil {
+		t.Fatalf("LoadCrawl: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for item := range results {
+		if item.Error != nil {
+			t.Fatalf("item %s errored: %v", item.URL, item.Error)
+		}
+		got[item.URL] = true
+	}
+	if len(got) != 2 || !got["https://a"] || !got["https://c"] {
+		t.Fatalf("unexpected result set: %v", got)
+	}
+
+	done, err := ReadCrawlCheckpoint(strings.NewReader(checkpoint.String()))
+	if err != nil {
+		t.Fatalf("ReadCrawlCheckpoint: %v", err)
+	}
+	if len(done) != 2 || !done["https://a"] || !done["https://c"] {
+		t.Fatalf("unexpected checkpoint set: %v", done)
+	}
+}
+
+func TestSaveBatchWithRetryResubmitsOnlyFailures(t *testing.T) {
+	var batches [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var opts SaveBatchOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			t.Errorf("decode batch: %v", err)
+		}
+		var urls []string
+		results := make([]map[string]any, 0, len(opts.Items))
+		for _, item := range opts.Items {
+			urls = append(urls, item.URL)
+			// b.com fails on the first batch only.
+			if item.URL == "https://b.com" && len(batches) == 0 {
+				results = append(results, map[string]any{
+					"url": item.URL, "status": "failed",
+					"error": map[string]any{"code": "TIMEOUT", "message": "slow"},
+				})
+				continue
+			}
+			results = append(results, map[string]any{"url": item.URL, "status": "saved"})
+		}
+		batches = append(batches, urls)
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "results": results})
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	res, err := client.SaveBatchWithRetry(context.Background(), &SaveBatchOptions{Items: []SaveOptions{
+		{URL: "https://a.com"}, {URL: "https://b.com"}, {URL: "https://c.com"},
+	}}, 3)
+	if err != nil {
+		t.Fatalf("SaveBatchWithRetry: %v", err)
+	}
+
+	if len(batches) != 2 {
+		t.Fatalf("server saw %d batches, want 2", len(batches))
+	}
+	if len(batches[1]) != 1 || batches[1][0] != "https://b.com" {
+		t.Fatalf("retry batch = %v, want only the failed URL", batches[1])
+	}
+	if len(res.Results) != 3 || res.Results[1].URL != "https://b.com" || res.Results[1].Status != "saved" {
+		t.Fatalf("results = %+v, want b.com recovered in input order", res.Results)
+	}
+	if res.Summary == nil || res.Summary.Saved != 3 || res.Summary.Failed != 0 {
+		t.Fatalf("summary = %+v, want 3 saved", res.Summary)
+	}
+}
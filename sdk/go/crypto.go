@@ -0,0 +1,77 @@
+package prismer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// encryptedMetadataKey marks a message whose content was transformed by a
+// MessageEncryptor, so the receiving side knows to run the decryptor.
+const encryptedMetadataKey = "_encrypted"
+
+// MessageEncryptor transforms outgoing message content before it is sent.
+// It returns the transformed content plus any extra metadata to attach
+// (key material references, algorithm identifiers, ...). The SDK does not
+// implement any cryptography itself; it only provides the interception
+// point and the metadata flag.
+type MessageEncryptor func(content string) (string, map[string]any, error)
+
+// MessageDecryptor reverses a MessageEncryptor on received content. It is
+// given the message metadata so it can find whatever the encryptor stored
+// there. It is only invoked for messages flagged as encrypted.
+type MessageDecryptor func(content string, metadata map[string]any) (string, error)
+
+// WithMessageEncryptor installs a hook applied to every outgoing message
+// send (direct, group, and offline-queued).
+func WithMessageEncryptor(enc MessageEncryptor) ClientOption {
+	return func(c *Client) { c.encryptor = enc }
+}
+
+// WithMessageDecryptor installs a hook applied to encrypted messages on the
+// receive paths: realtime events, offline cache fills and sync events.
+func WithMessageDecryptor(dec MessageDecryptor) ClientOption {
+	return func(c *Client) { c.decryptor = dec }
+}
+
+// encryptPayload runs the client's encryptor over a send payload in place,
+// merging hook metadata and setting the encrypted flag.
+func (c *Client) encryptPayload(payload map[string]any) error {
+	if c.encryptor == nil {
+		return nil
+	}
+	content, _ := payload["content"].(string)
+	transformed, extra, err := c.encryptor(content)
+	if err != nil {
+		return fmt.Errorf("message encryptor: %w", err)
+	}
+	payload["content"] = transformed
+	meta, _ := payload["metadata"].(map[string]any)
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	for k, v := range extra {
+		meta[k] = v
+	}
+	meta[encryptedMetadataKey] = true
+	payload["metadata"] = meta
+	return nil
+}
+
+// decryptIMMessage applies dec to msg in place when its metadata carries
+// the encrypted flag. Decryption failures leave the content untouched so
+// the ciphertext remains inspectable.
+func decryptIMMessage(dec MessageDecryptor, msg *IMMessage) {
+	if dec == nil || len(msg.Metadata) == 0 {
+		return
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(msg.Metadata, &meta); err != nil {
+		return
+	}
+	if flagged, _ := meta[encryptedMetadataKey].(bool); !flagged {
+		return
+	}
+	if plain, err := dec(msg.Content, meta); err == nil {
+		msg.Content = plain
+	}
+}
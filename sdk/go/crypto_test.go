@@ -0,0 +1,54 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMessageEncryptorAppliedOnSend(t *testing.T) {
+	var sent map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_ = json.NewEncoder(w).Encode(IMResult{Success: true})
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL), WithMessageEncryptor(func(content string) (string, map[string]any, error) {
+		return "enc(" + content + ")", map[string]any{"_alg": "test"}, nil
+	}))
+	if _, err := client.IM().Direct.SendMessage(context.Background(), "u1", "secret", nil); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	if sent["content"] != "enc(secret)" {
+		t.Fatalf("content = %v, want transformed ciphertext", sent["content"])
+	}
+	meta, _ := sent["metadata"].(map[string]any)
+	if flagged, _ := meta["_encrypted"].(bool); !flagged {
+		t.Fatalf("metadata missing encrypted flag: %v", meta)
+	}
+	if meta["_alg"] != "test" {
+		t.Fatalf("hook metadata not merged: %v", meta)
+	}
+}
+
+func TestMessageDecryptorAppliedOnEncryptedOnly(t *testing.T) {
+	dec := func(content string, metadata map[string]any) (string, error) {
+		return "plain", nil
+	}
+
+	encrypted := IMMessage{Content: "cipher", Metadata: json.RawMessage(`{"_encrypted":true}`)}
+	decryptIMMessage(dec, &encrypted)
+	if encrypted.Content != "plain" {
+		t.Fatalf("encrypted message not decrypted: %q", encrypted.Content)
+	}
+
+	clear := IMMessage{Content: "hello", Metadata: json.RawMessage(`{"foo":1}`)}
+	decryptIMMessage(dec, &clear)
+	if clear.Content != "hello" {
+		t.Fatalf("unflagged message was transformed: %q", clear.Content)
+	}
+}
@@ -0,0 +1,93 @@
+package prismer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// sniffLen is how many bytes Download buffers for MIME detection when
+// the server does not declare a content type.
+const sniffLen = 512
+
+// Download fetches an uploaded file's bytes by file ID, returning the
+// content and its MIME type. The request carries the client's auth
+// headers, so non-public files resolve too; redirects to external CDN
+// storage are followed (the transport drops the Authorization header on
+// cross-host redirects). For large files prefer DownloadTo, which
+// streams instead of buffering.
+func (f *FilesClient) Download(ctx context.Context, fileID string) ([]byte, string, error) {
+	resp, err := f.openDownload(ctx, fileID)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("download read failed: %w", err)
+	}
+	return data, detectMIME(resp, data), nil
+}
+
+// DownloadTo streams an uploaded file's bytes into w, returning the MIME
+// type, for files too large to hold in memory.
+func (f *FilesClient) DownloadTo(ctx context.Context, fileID string, w io.Writer) (string, error) {
+	resp, err := f.openDownload(ctx, fileID)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Sniff the first bytes before streaming, so the MIME type is real
+	// even when the server answers with a generic content type.
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(resp.Body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("download read failed: %w", err)
+	}
+	head = head[:n]
+	mime := detectMIME(resp, head)
+	if _, err := w.Write(head); err != nil {
+		return mime, err
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return mime, fmt.Errorf("download read failed: %w", err)
+	}
+	return mime, nil
+}
+
+// openDownload issues the authenticated download request and validates
+// the status, leaving the body open for the caller.
+func (f *FilesClient) openDownload(ctx context.Context, fileID string) (*http.Response, error) {
+	if f.c.initErr != nil {
+		return nil, f.c.initErr
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.c.baseURL+"/api/im/files/"+fileID+"/download", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build download request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+f.c.token())
+	req.Header.Set("User-Agent", f.c.userAgent)
+
+	resp, err := f.c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+	return resp, nil
+}
+
+// detectMIME prefers the declared content type and falls back to
+// sniffing the payload when the server stays generic.
+func detectMIME(resp *http.Response, data []byte) string {
+	ct := resp.Header.Get("Content-Type")
+	if ct != "" && ct != "application/octet-stream" {
+		return ct
+	}
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,60 @@
+package prismer
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDownloadReturnsBytesAndSniffedMIME(t *testing.T) {
+	content := "%PDF-1.4 fake document body"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/im/files/f1/download" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			t.Error("download request carried no auth header")
+		}
+		// Generic content type forces the client to sniff.
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	data, mime, err := client.IM().Files.Download(context.Background(), "f1")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if string(data) != content {
+		t.Fatalf("data = %q", data)
+	}
+	if mime != "application/pdf" {
+		t.Fatalf("mime = %q, want application/pdf", mime)
+	}
+}
+
+func TestDownloadToStreamsLargeBody(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 2048)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	var buf bytes.Buffer
+	mime, err := client.IM().Files.DownloadTo(context.Background(), "f2", &buf)
+	if err != nil {
+		t.Fatalf("DownloadTo: %v", err)
+	}
+	if mime != "text/plain; charset=utf-8" {
+		t.Fatalf("mime = %q", mime)
+	}
+	if !bytes.Equal(buf.Bytes(), body) {
+		t.Fatalf("streamed %d bytes, want %d", buf.Len(), len(body))
+	}
+}
@@ -0,0 +1,55 @@
+package prismer
+
+import "errors"
+
+// Sentinel errors returned across the SDK. They are wrapped with
+// additional context at the call site, so match them with errors.Is
+// rather than string comparison. API-level failures are reported as
+// *APIError instead and are matched with errors.As.
+var (
+	// ErrNotConnected is returned by realtime operations attempted on a
+	// client that is not currently connected.
+	ErrNotConnected = errors.New("prismer: not connected")
+
+	// ErrPingTimeout is returned when a heartbeat ping gets no pong
+	// within RealtimeConfig.PingTimeout.
+	ErrPingTimeout = errors.New("prismer: ping timeout")
+
+	// ErrNoData is returned when a successful result carries no payload
+	// to decode.
+	ErrNoData = errors.New("prismer: result has no data")
+
+	// ErrAuthFailed is returned when realtime authentication does not
+	// complete, either because the server rejected the token or never
+	// acknowledged it.
+	ErrAuthFailed = errors.New("prismer: authentication failed")
+
+	// ErrBatchTooLarge is returned when a batch operation exceeds the
+	// server's per-request item limit.
+	ErrBatchTooLarge = errors.New("prismer: batch too large")
+
+	// ErrFileTooLarge is returned when a local file exceeds the maximum
+	// size accepted for upload or parsing.
+	ErrFileTooLarge = errors.New("prismer: file too large")
+
+	// ErrQuotaExceeded is returned by the upload pre-flight when a file
+	// would not fit in the account's remaining storage quota.
+	ErrQuotaExceeded = errors.New("prismer: storage quota exceeded")
+
+	// ErrTypeNotAllowed is returned by the upload pre-flight when a
+	// file's MIME type is not on the server's allow-list.
+	ErrTypeNotAllowed = errors.New("prismer: file type not allowed")
+
+	// ErrInvalidMessageType is returned by sends whose options carry a
+	// message type outside the MessageType* constants, unless
+	// ValidateMessageTypes is disabled.
+	ErrInvalidMessageType = errors.New("prismer: invalid message type")
+
+	// ErrNoAgentAvailable is returned by agent selection when no
+	// registered agent matches the requested capability.
+	ErrNoAgentAvailable = errors.New("prismer: no agent available with requested capability")
+
+	// ErrContentNotFound is returned by cache lookups when no stored
+	// content matches the given hash.
+	ErrContentNotFound = errors.New("prismer: no cached content matches hash")
+)
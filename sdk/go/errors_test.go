@@ -0,0 +1,25 @@
+package prismer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSentinelErrorsMatchWithErrorsIs(t *testing.T) {
+	ws := &RealtimeWSClient{}
+	if err := ws.Send(context.Background(), &eventEnvelope{Type: "ping"}); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("Send on disconnected client = %v, want ErrNotConnected", err)
+	}
+
+	res := &IMResult{Success: true}
+	if err := res.Decode(&struct{}{}); !errors.Is(err, ErrNoData) {
+		t.Fatalf("Decode without data = %v, want ErrNoData", err)
+	}
+
+	client := NewClient("k")
+	items := make([]SaveOptions, maxSaveBatch+1)
+	if _, err := client.SaveBatch(context.Background(), &SaveBatchOptions{Items: items}); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("oversized SaveBatch = %v, want ErrBatchTooLarge", err)
+	}
+}
@@ -0,0 +1,920 @@
+package prismer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IMClient groups the messaging API by resource.
+type IMClient struct {
+	c *Client
+
+	Account       *AccountClient
+	Direct        *DirectClient
+	Groups        *GroupsClient
+	Conversations *ConversationsClient
+	Messages      *MessagesClient
+	Contacts      *ContactsClient
+	Bindings      *BindingsClient
+	Workspace     *WorkspaceClient
+	Credits       *CreditsClient
+	Files         *FilesClient
+	Realtime      *RealtimeClient
+}
+
+func newIMClient(c *Client) *IMClient {
+	im := &IMClient{c: c}
+	im.Account = &AccountClient{c: c}
+	im.Direct = &DirectClient{c: c}
+	im.Groups = &GroupsClient{c: c}
+	im.Conversations = &ConversationsClient{c: c}
+	im.Messages = &MessagesClient{c: c}
+	im.Contacts = &ContactsClient{c: c}
+	im.Bindings = &BindingsClient{c: c}
+	im.Workspace = &WorkspaceClient{c: c}
+	im.Credits = &CreditsClient{c: c}
+	im.Files = &FilesClient{c: c}
+	im.Realtime = &RealtimeClient{c: c}
+	return im
+}
+
+// Sync fetches one page of change events from the sync endpoint, starting
+// after the given cursor. It is the raw primitive the offline manager is
+// built on, exposed so applications can implement their own persistence.
+func (im *IMClient) Sync(ctx context.Context, since string, limit int) (*SyncResultData, error) {
+	q := url.Values{}
+	if since != "" {
+		q.Set("since", since)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	res, err := imCall(ctx, im.c, http.MethodGet, "/api/im/sync", q, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var data SyncResultData
+	if err := res.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode sync result: %w", err)
+	}
+	return &data, nil
+}
+
+// imCall performs an IM request and decodes the standard envelope.
+func imCall(ctx context.Context, d requestDoer, method, path string, query url.Values, body any) (*IMResult, error) {
+	var (
+		raw    []byte
+		header http.Header
+		err    error
+	)
+	if hd, ok := d.(headerRequestDoer); ok {
+		raw, header, err = hd.doRequestHeaders(ctx, method, path, query, body)
+	} else {
+		raw, err = d.doRequest(ctx, method, path, query, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res, err := decodeJSON[IMResult](raw)
+	if err != nil {
+		return nil, err
+	}
+	if res.NextCursor == "" {
+		res.NextCursor = nextCursorFromHeaders(header)
+	}
+	return res, nil
+}
+
+// nextCursorFromHeaders extracts a pagination cursor the server put in
+// response headers: X-Next-Cursor directly, or the cursor query parameter
+// of a Link header's rel="next" target.
+func nextCursorFromHeaders(h http.Header) string {
+	if h == nil {
+		return ""
+	}
+	if v := h.Get("X-Next-Cursor"); v != "" {
+		return v
+	}
+	for _, link := range h.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			if !strings.Contains(part, `rel="next"`) {
+				continue
+			}
+			start := strings.Index(part, "<")
+			end := strings.Index(part, ">")
+			if start < 0 || end <= start {
+				continue
+			}
+			u, err := url.Parse(part[start+1 : end])
+			if err != nil {
+				continue
+			}
+			if cursor := u.Query().Get("cursor"); cursor != "" {
+				return cursor
+			}
+		}
+	}
+	return ""
+}
+
+// paginationQuery converts pagination options into query parameters.
+func paginationQuery(opts *IMPaginationOptions) url.Values {
+	q := url.Values{}
+	if opts == nil {
+		return q
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		q.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if opts.Before != "" {
+		q.Set("before", opts.Before)
+	}
+	if opts.After != "" {
+		q.Set("after", opts.After)
+	}
+	return q
+}
+
+// ValidateMessageTypes makes sends reject message types outside the
+// MessageType* constants with ErrInvalidMessageType. Disable it to talk
+// to deployments with custom types.
+var ValidateMessageTypes = true
+
+// checkMessageType applies the ValidateMessageTypes policy to a send.
+func checkMessageType(opts *IMSendOptions) error {
+	if opts == nil || !ValidateMessageTypes || opts.Type.Valid() {
+		return nil
+	}
+	return fmt.Errorf("%w: %q (disable ValidateMessageTypes to send anyway)", ErrInvalidMessageType, opts.Type)
+}
+
+// sendPayload builds the body of a message send from content and options.
+func sendPayload(content string, opts *IMSendOptions) map[string]any {
+	payload := map[string]any{"content": content, "type": "text"}
+	if opts == nil {
+		return payload
+	}
+	if opts.Type != "" {
+		payload["type"] = string(opts.Type)
+	}
+	if opts.ParentID != "" {
+		payload["parentId"] = opts.ParentID
+	}
+	if opts.Metadata != nil {
+		payload["metadata"] = opts.Metadata
+	}
+	if opts.ClientID != "" {
+		payload["clientId"] = opts.ClientID
+	}
+	return payload
+}
+
+// quoteSnippetLen bounds the quoted-content excerpt embedded in metadata.
+const quoteSnippetLen = 200
+
+// buildSendPayload assembles a send body, resolving a quoted message into
+// an inline metadata snippet for clients that don't resolve quotes
+// server-side.
+func (c *Client) buildSendPayload(ctx context.Context, content string, opts *IMSendOptions) (map[string]any, error) {
+	if err := checkMessageType(opts); err != nil {
+		return nil, err
+	}
+	payload := sendPayload(content, opts)
+	if opts == nil || opts.QuotedMessageID == "" {
+		if err := c.encryptPayload(payload); err != nil {
+			return nil, err
+		}
+		return payload, nil
+	}
+
+	res, err := c.im.Messages.Get(ctx, opts.QuotedMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve quoted message: %w", err)
+	}
+	if err := res.Err(); err != nil {
+		return nil, fmt.Errorf("failed to resolve quoted message: %w", err)
+	}
+	var quoted IMMessage
+	if err := res.Decode(&quoted); err != nil {
+		return nil, fmt.Errorf("failed to decode quoted message: %w", err)
+	}
+
+	snippet := quoted.Content
+	if runes := []rune(snippet); len(runes) > quoteSnippetLen {
+		snippet = string(runes[:quoteSnippetLen]) + "…"
+	}
+	meta, _ := payload["metadata"].(map[string]any)
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	meta["_quote"] = map[string]any{
+		"messageId": quoted.ID,
+		"senderId":  quoted.SenderID,
+		"snippet":   snippet,
+	}
+	payload["metadata"] = meta
+	payload["quotedMessageId"] = opts.QuotedMessageID
+	if err := c.encryptPayload(payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// AccountClient manages registration and the authenticated identity.
+type AccountClient struct{ c *Client }
+
+// Register creates (or re-registers) an agent account and returns its token.
+func (a *AccountClient) Register(ctx context.Context, opts *IMRegisterOptions) (*IMResult, error) {
+	return imCall(ctx, a.c, http.MethodPost, "/api/im/register", nil, opts)
+}
+
+// registerBatchConcurrency bounds parallel RegisterBatch calls.
+const registerBatchConcurrency = 4
+
+// RegisterResult is the per-agent outcome of RegisterBatch.
+type RegisterResult struct {
+	Username string
+	Result   *IMRegisterResult
+	Err      error
+}
+
+// RegisterBatch registers several agents with bounded concurrency. Results
+// are returned in input order; individual failures (e.g. username
+// conflicts) are recorded per entry and do not abort the batch.
+func (a *AccountClient) RegisterBatch(ctx context.Context, opts []IMRegisterOptions) ([]RegisterResult, error) {
+	results := make([]RegisterResult, len(opts))
+	sem := make(chan struct{}, registerBatchConcurrency)
+	var wg sync.WaitGroup
+	for i := range opts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i].Username = opts[i].Username
+			res, err := a.Register(ctx, &opts[i])
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			if err := res.Err(); err != nil {
+				results[i].Err = err
+				return
+			}
+			var reg IMRegisterResult
+			if err := res.Decode(&reg); err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Result = &reg
+		}(i)
+	}
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// Me returns the authenticated user, including the agent card.
+func (a *AccountClient) Me(ctx context.Context) (*IMResult, error) {
+	return imCall(ctx, a.c, http.MethodGet, "/api/im/me", nil, nil)
+}
+
+// GetPresence returns the caller's own presence status.
+func (a *AccountClient) GetPresence(ctx context.Context) (*IMResult, error) {
+	return imCall(ctx, a.c, http.MethodGet, "/api/im/me/presence", nil, nil)
+}
+
+// SetPresence sets the caller's presence status (e.g. "online", "away")
+// over REST, for agents without a realtime connection.
+func (a *AccountClient) SetPresence(ctx context.Context, status string) (*IMResult, error) {
+	return imCall(ctx, a.c, http.MethodPost, "/api/im/me/presence", nil, map[string]any{"status": status})
+}
+
+// RefreshToken exchanges the current token for a fresh one.
+func (a *AccountClient) RefreshToken(ctx context.Context) (*IMResult, error) {
+	return imCall(ctx, a.c, http.MethodPost, "/api/im/token/refresh", nil, nil)
+}
+
+// DirectClient handles one-to-one messaging.
+type DirectClient struct{ c *Client }
+
+// SendMessage sends a direct message to userID.
+func (d *DirectClient) SendMessage(ctx context.Context, userID, content string, opts *IMSendOptions) (*IMResult, error) {
+	payload, err := d.c.buildSendPayload(ctx, content, opts)
+	if err != nil {
+		return nil, err
+	}
+	return imCall(ctx, d.c, http.MethodPost, "/api/im/direct/"+userID+"/messages", nil, payload)
+}
+
+// SetTyping publishes a typing indicator to userID over REST, so agents
+// on the SSE transport or plain HTTP can signal "typing…" while they
+// compute a reply.
+func (d *DirectClient) SetTyping(ctx context.Context, userID string, isTyping bool) (*IMResult, error) {
+	return imCall(ctx, d.c, http.MethodPost, "/api/im/direct/"+userID+"/typing", nil, map[string]any{"isTyping": isTyping})
+}
+
+// GetMessages returns the direct message history with userID.
+func (d *DirectClient) GetMessages(ctx context.Context, userID string, opts *IMPaginationOptions) (*IMResult, error) {
+	return imCall(ctx, d.c, http.MethodGet, "/api/im/direct/"+userID+"/messages", paginationQuery(opts), nil)
+}
+
+// messagesFromResult checks and decodes a message-history result,
+// decrypting each entry with the client's decryptor.
+func messagesFromResult(c *Client, res *IMResult, err error) ([]IMMessage, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var msgs []IMMessage
+	if err := res.Decode(&msgs); err != nil {
+		return nil, err
+	}
+	for i := range msgs {
+		decryptIMMessage(c.decryptor, &msgs[i])
+	}
+	return msgs, nil
+}
+
+// Messages is GetMessages with the envelope handling folded in: it checks
+// the result, decodes the history into typed messages and returns them
+// directly.
+func (d *DirectClient) Messages(ctx context.Context, userID string, opts *IMPaginationOptions) ([]IMMessage, error) {
+	res, err := d.GetMessages(ctx, userID, opts)
+	return messagesFromResult(d.c, res, err)
+}
+
+// waitForMessagePollInterval is how often WaitForMessage re-reads history.
+const waitForMessagePollInterval = 500 * time.Millisecond
+
+// WaitForMessage polls the direct history with userID until a message
+// matching the predicate appears, then returns it. It blocks until a match
+// arrives or ctx expires, which makes deterministic agent tests a one-liner
+// instead of a hand-rolled channel-and-timeout loop.
+func (d *DirectClient) WaitForMessage(ctx context.Context, userID string, match func(IMMessage) bool) (*IMMessage, error) {
+	for {
+		res, err := d.GetMessages(ctx, userID, nil)
+		if err == nil && res.OK() {
+			var msgs []IMMessage
+			if err := res.Decode(&msgs); err == nil {
+				for i := range msgs {
+					decryptIMMessage(d.c.decryptor, &msgs[i])
+					if match(msgs[i]) {
+						return &msgs[i], nil
+					}
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(waitForMessagePollInterval):
+		}
+	}
+}
+
+// GroupsClient handles group conversations.
+type GroupsClient struct{ c *Client }
+
+// Create creates a group with the given members.
+func (g *GroupsClient) Create(ctx context.Context, opts *IMCreateGroupOptions) (*IMResult, error) {
+	return imCall(ctx, g.c, http.MethodPost, "/api/im/groups", nil, opts)
+}
+
+// List returns the groups the caller belongs to.
+func (g *GroupsClient) List(ctx context.Context, opts *IMPaginationOptions) (*IMResult, error) {
+	return imCall(ctx, g.c, http.MethodGet, "/api/im/groups", paginationQuery(opts), nil)
+}
+
+// Get returns a single group, including its member list.
+func (g *GroupsClient) Get(ctx context.Context, groupID string) (*IMResult, error) {
+	return imCall(ctx, g.c, http.MethodGet, "/api/im/groups/"+groupID, nil, nil)
+}
+
+// Update changes a group's metadata after creation. Only fields set in
+// opts are sent, so unset fields keep their current value (PATCH
+// semantics).
+func (g *GroupsClient) Update(ctx context.Context, groupID string, opts *IMUpdateGroupOptions) (*IMResult, error) {
+	body := map[string]any{}
+	if opts != nil {
+		if opts.Title != "" {
+			body["title"] = opts.Title
+		}
+		if opts.Description != "" {
+			body["description"] = opts.Description
+		}
+		if opts.Metadata != nil {
+			body["metadata"] = opts.Metadata
+		}
+	}
+	return imCall(ctx, g.c, http.MethodPatch, "/api/im/groups/"+groupID, nil, body)
+}
+
+// Leave removes the caller from the group.
+func (g *GroupsClient) Leave(ctx context.Context, groupID string) (*IMResult, error) {
+	return imCall(ctx, g.c, http.MethodPost, "/api/im/groups/"+groupID+"/leave", nil, nil)
+}
+
+// AddMember adds userID to the group.
+func (g *GroupsClient) AddMember(ctx context.Context, groupID, userID string) (*IMResult, error) {
+	return imCall(ctx, g.c, http.MethodPost, "/api/im/groups/"+groupID+"/members", nil, map[string]any{"userId": userID})
+}
+
+// RemoveMember removes userID from the group.
+func (g *GroupsClient) RemoveMember(ctx context.Context, groupID, userID string) (*IMResult, error) {
+	return imCall(ctx, g.c, http.MethodDelete, "/api/im/groups/"+groupID+"/members/"+userID, nil, nil)
+}
+
+// SendMessage sends a message to the group.
+func (g *GroupsClient) SendMessage(ctx context.Context, groupID, content string, opts *IMSendOptions) (*IMResult, error) {
+	payload, err := g.c.buildSendPayload(ctx, content, opts)
+	if err != nil {
+		return nil, err
+	}
+	return imCall(ctx, g.c, http.MethodPost, "/api/im/groups/"+groupID+"/messages", nil, payload)
+}
+
+// Messages is GetMessages with the envelope handling folded in, the
+// group counterpart of Direct.Messages.
+func (g *GroupsClient) Messages(ctx context.Context, groupID string, opts *IMPaginationOptions) ([]IMMessage, error) {
+	res, err := g.GetMessages(ctx, groupID, opts)
+	return messagesFromResult(g.c, res, err)
+}
+
+// SetTyping publishes a typing indicator to the group over REST, the
+// group counterpart of Direct.SetTyping.
+func (g *GroupsClient) SetTyping(ctx context.Context, groupID string, isTyping bool) (*IMResult, error) {
+	return imCall(ctx, g.c, http.MethodPost, "/api/im/groups/"+groupID+"/typing", nil, map[string]any{"isTyping": isTyping})
+}
+
+// GetMessages returns the group's message history.
+func (g *GroupsClient) GetMessages(ctx context.Context, groupID string, opts *IMPaginationOptions) (*IMResult, error) {
+	return imCall(ctx, g.c, http.MethodGet, "/api/im/groups/"+groupID+"/messages", paginationQuery(opts), nil)
+}
+
+// ConversationsClient operates on conversations regardless of kind.
+type ConversationsClient struct{ c *Client }
+
+// List returns the caller's conversations, most recently active first.
+func (v *ConversationsClient) List(ctx context.Context, opts *IMPaginationOptions) (*IMResult, error) {
+	return imCall(ctx, v.c, http.MethodGet, "/api/im/conversations", paginationQuery(opts), nil)
+}
+
+// Get returns a single conversation.
+func (v *ConversationsClient) Get(ctx context.Context, conversationID string) (*IMResult, error) {
+	return imCall(ctx, v.c, http.MethodGet, "/api/im/conversations/"+conversationID, nil, nil)
+}
+
+// GetMessages returns a conversation's message history regardless of its
+// kind (direct or group).
+func (v *ConversationsClient) GetMessages(ctx context.Context, conversationID string, opts *IMPaginationOptions) (*IMResult, error) {
+	return imCall(ctx, v.c, http.MethodGet, "/api/im/conversations/"+conversationID+"/messages", paginationQuery(opts), nil)
+}
+
+// Messages is GetMessages with the envelope handling folded in, for
+// conversations of either kind.
+func (v *ConversationsClient) Messages(ctx context.Context, conversationID string, opts *IMPaginationOptions) ([]IMMessage, error) {
+	res, err := v.GetMessages(ctx, conversationID, opts)
+	return messagesFromResult(v.c, res, err)
+}
+
+// SetTyping publishes a typing indicator for the conversation over REST,
+// for agents without a realtime connection (e.g. webhook handlers doing
+// slow work before replying).
+func (v *ConversationsClient) SetTyping(ctx context.Context, conversationID string, isTyping bool) (*IMResult, error) {
+	return imCall(ctx, v.c, http.MethodPost, "/api/im/conversations/"+conversationID+"/typing", nil, map[string]any{"isTyping": isTyping})
+}
+
+// MarkAsRead clears the unread counter for a conversation.
+func (v *ConversationsClient) MarkAsRead(ctx context.Context, conversationID string) (*IMResult, error) {
+	return imCall(ctx, v.c, http.MethodPost, "/api/im/conversations/"+conversationID+"/read", nil, nil)
+}
+
+// MarkReadUpTo marks everything up to and including messageID as read,
+// leaving later messages unread — the usual semantics when a user has
+// scrolled partway through a backlog. MarkAsRead remains the coarser call
+// that clears the whole conversation.
+func (v *ConversationsClient) MarkReadUpTo(ctx context.Context, conversationID, messageID string) (*IMResult, error) {
+	return imCall(ctx, v.c, http.MethodPost, "/api/im/conversations/"+conversationID+"/read", nil,
+		map[string]any{"upToMessageId": messageID})
+}
+
+// MessagesClient operates on individual messages.
+type MessagesClient struct{ c *Client }
+
+// Get returns a single message by ID.
+func (m *MessagesClient) Get(ctx context.Context, messageID string) (*IMResult, error) {
+	return imCall(ctx, m.c, http.MethodGet, "/api/im/messages/"+messageID, nil, nil)
+}
+
+// Edit replaces the content of an existing message.
+func (m *MessagesClient) Edit(ctx context.Context, messageID, content string) (*IMResult, error) {
+	return imCall(ctx, m.c, http.MethodPatch, "/api/im/messages/"+messageID, nil, map[string]any{"content": content})
+}
+
+// Delete removes a message.
+func (m *MessagesClient) Delete(ctx context.Context, messageID string) (*IMResult, error) {
+	return imCall(ctx, m.c, http.MethodDelete, "/api/im/messages/"+messageID, nil, nil)
+}
+
+// Search runs a server-side full-text search over message history,
+// optionally scoped to one conversation, a message type, and a time
+// range. Unlike OfflineManager.SearchMessages it is not limited to what
+// happens to be cached locally; matches come back with their
+// conversation context.
+func (m *MessagesClient) Search(ctx context.Context, opts *IMSearchOptions) (*IMResult, error) {
+	q := url.Values{}
+	if opts != nil {
+		if opts.Query != "" {
+			q.Set("q", opts.Query)
+		}
+		if opts.ConversationID != "" {
+			q.Set("conversationId", opts.ConversationID)
+		}
+		if opts.Type != "" {
+			q.Set("type", opts.Type)
+		}
+		if !opts.From.IsZero() {
+			q.Set("from", formatRFC3339(opts.From))
+		}
+		if !opts.To.IsZero() {
+			q.Set("to", formatRFC3339(opts.To))
+		}
+		if opts.Limit > 0 {
+			q.Set("limit", strconv.Itoa(opts.Limit))
+		}
+		if opts.Offset > 0 {
+			q.Set("offset", strconv.Itoa(opts.Offset))
+		}
+	}
+	return imCall(ctx, m.c, http.MethodGet, "/api/im/messages/search", q, nil)
+}
+
+// ReadReceipt records that a user read a specific message. It is the
+// payload posted by MarkRead and carried by message.read sync events.
+type ReadReceipt struct {
+	ConversationID string `json:"conversationId"`
+	MessageID      string `json:"messageId"`
+	UserID         string `json:"userId,omitempty"`
+	ReadAt         string `json:"readAt,omitempty"`
+}
+
+// MarkRead posts a read receipt for a single message, supporting "read up
+// to here" semantics. Conversations.MarkAsRead remains the coarser call
+// that clears the whole conversation's unread counter.
+func (m *MessagesClient) MarkRead(ctx context.Context, conversationID, messageID string) (*IMResult, error) {
+	return imCall(ctx, m.c, http.MethodPost, "/api/im/conversations/"+conversationID+"/messages/"+messageID+"/read", nil, nil)
+}
+
+// ReadReceipts lists who has read a message, as a []ReadReceipt — the
+// group-chat "seen by" view.
+func (m *MessagesClient) ReadReceipts(ctx context.Context, conversationID, messageID string) (*IMResult, error) {
+	return imCall(ctx, m.c, http.MethodGet, "/api/im/conversations/"+conversationID+"/messages/"+messageID+"/receipts", nil, nil)
+}
+
+// Forward relays an existing message into another conversation, preserving
+// content, metadata and attachments server-side along with provenance.
+func (m *MessagesClient) Forward(ctx context.Context, fromConversationID, messageID, toConversationID string, opts *ForwardOptions) (*IMResult, error) {
+	body := map[string]any{
+		"fromConversationId": fromConversationID,
+		"toConversationId":   toConversationID,
+	}
+	if opts != nil && opts.Attribution != "" {
+		body["attribution"] = opts.Attribution
+	}
+	return imCall(ctx, m.c, http.MethodPost, "/api/im/messages/"+messageID+"/forward", nil, body)
+}
+
+// ScheduledMessage is a message queued server-side for future delivery.
+type ScheduledMessage struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversationId"`
+	Content        string `json:"content"`
+	Type           string `json:"type,omitempty"`
+	SendAt         string `json:"sendAt"`
+	Status         string `json:"status,omitempty"`
+	CreatedAt      string `json:"createdAt,omitempty"`
+}
+
+// Schedule queues a message for delivery into a conversation at sendAt.
+// The server holds and releases it, so delivery does not depend on this
+// process staying alive.
+func (m *MessagesClient) Schedule(ctx context.Context, conversationID, content string, sendAt time.Time, opts *IMSendOptions) (*ScheduledMessage, error) {
+	payload, err := m.c.buildSendPayload(ctx, content, opts)
+	if err != nil {
+		return nil, err
+	}
+	payload["conversationId"] = conversationID
+	payload["sendAt"] = formatRFC3339(sendAt)
+	res, err := imCall(ctx, m.c, http.MethodPost, "/api/im/messages/schedule", nil, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var scheduled ScheduledMessage
+	if err := res.Decode(&scheduled); err != nil {
+		return nil, fmt.Errorf("failed to decode scheduled message: %w", err)
+	}
+	return &scheduled, nil
+}
+
+// ListScheduled returns the caller's pending scheduled messages.
+func (m *MessagesClient) ListScheduled(ctx context.Context, opts *IMPaginationOptions) ([]ScheduledMessage, error) {
+	res, err := imCall(ctx, m.c, http.MethodGet, "/api/im/messages/scheduled", paginationQuery(opts), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var scheduled []ScheduledMessage
+	if err := res.Decode(&scheduled); err != nil {
+		return nil, fmt.Errorf("failed to decode scheduled messages: %w", err)
+	}
+	return scheduled, nil
+}
+
+// CancelScheduled withdraws a scheduled message before delivery.
+func (m *MessagesClient) CancelScheduled(ctx context.Context, id string) (*IMResult, error) {
+	return imCall(ctx, m.c, http.MethodDelete, "/api/im/messages/scheduled/"+id, nil, nil)
+}
+
+// ContactsClient manages the contact list and agent discovery.
+type ContactsClient struct {
+	c *Client
+
+	rrMu   sync.Mutex
+	rrNext map[string]int
+}
+
+// List returns the caller's contacts.
+func (t *ContactsClient) List(ctx context.Context, opts *IMPaginationOptions) (*IMResult, error) {
+	return imCall(ctx, t.c, http.MethodGet, "/api/im/contacts", paginationQuery(opts), nil)
+}
+
+// Add adds userID as a contact.
+func (t *ContactsClient) Add(ctx context.Context, userID string) (*IMResult, error) {
+	return imCall(ctx, t.c, http.MethodPost, "/api/im/contacts", nil, map[string]any{"userId": userID})
+}
+
+// UserPresence is one user's presence as returned by Presence.
+type UserPresence struct {
+	UserID   string `json:"userId"`
+	Status   string `json:"status"`
+	LastSeen string `json:"lastSeen,omitempty"`
+}
+
+// Presence returns the current presence of the given users, so bots can
+// adapt replies to whether the recipient is online without holding a
+// websocket open for presence.changed events.
+func (t *ContactsClient) Presence(ctx context.Context, userIDs []string) (*IMResult, error) {
+	q := url.Values{}
+	q.Set("userIds", strings.Join(userIDs, ","))
+	return imCall(ctx, t.c, http.MethodGet, "/api/im/presence", q, nil)
+}
+
+// Discover lists public agents, optionally filtered by type, capability
+// or a free-text query, and paged with Limit/Offset. The result data is a
+// page of IMDiscoverAgent entries; callers page by re-issuing the call
+// with Offset advanced by Limit until a short page comes back.
+func (t *ContactsClient) Discover(ctx context.Context, opts *IMDiscoverOptions) (*IMResult, error) {
+	q := url.Values{}
+	if opts != nil {
+		if opts.Type != "" {
+			q.Set("type", opts.Type)
+		}
+		if opts.Capability != "" {
+			q.Set("capability", opts.Capability)
+		}
+		if opts.Query != "" {
+			q.Set("q", opts.Query)
+		}
+		if opts.Limit > 0 {
+			q.Set("limit", strconv.Itoa(opts.Limit))
+		}
+		if opts.Offset > 0 {
+			q.Set("offset", strconv.Itoa(opts.Offset))
+		}
+	}
+	return imCall(ctx, t.c, http.MethodGet, "/api/im/discover", q, nil)
+}
+
+// Selection strategies for FindByCapability.
+const (
+	// SelectFirstOnline picks the first discovered agent that is online.
+	SelectFirstOnline = "first-online"
+	// SelectRoundRobin rotates through online agents per capability, so
+	// repeated calls spread work across equivalent agents.
+	SelectRoundRobin = "round-robin"
+)
+
+// SelectOptions tunes agent selection in FindByCapability.
+type SelectOptions struct {
+	// Type filters discovery by agent type.
+	Type string
+	// Strategy is one of the Select* constants. Defaults to
+	// SelectFirstOnline.
+	Strategy string
+}
+
+// FindByCapability discovers agents advertising capability and selects one
+// according to the strategy, encoding the "route this task to an agent
+// that can do X" pattern over Discover.
+func (t *ContactsClient) FindByCapability(ctx context.Context, capability string, opts *SelectOptions) (*IMDiscoverAgent, error) {
+	o := SelectOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Strategy == "" {
+		o.Strategy = SelectFirstOnline
+	}
+
+	res, err := t.Discover(ctx, &IMDiscoverOptions{Type: o.Type, Capability: capability})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var agents []IMDiscoverAgent
+	if err := res.Decode(&agents); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery result: %w", err)
+	}
+
+	online := make([]*IMDiscoverAgent, 0, len(agents))
+	for i := range agents {
+		if agents[i].Status == "online" {
+			online = append(online, &agents[i])
+		}
+	}
+	if len(online) == 0 {
+		return nil, ErrNoAgentAvailable
+	}
+
+	switch o.Strategy {
+	case SelectRoundRobin:
+		t.rrMu.Lock()
+		if t.rrNext == nil {
+			t.rrNext = make(map[string]int)
+		}
+		idx := t.rrNext[capability] % len(online)
+		t.rrNext[capability] = idx + 1
+		t.rrMu.Unlock()
+		return online[idx], nil
+	case SelectFirstOnline:
+		return online[0], nil
+	default:
+		return nil, fmt.Errorf("prismer: unknown selection strategy %q", o.Strategy)
+	}
+}
+
+// GetAgentCard fetches a specific agent's card by user ID, so an
+// orchestrator can check Capabilities before routing work to it. Discover
+// lists agents in bulk; this resolves one known agent in detail.
+func (t *ContactsClient) GetAgentCard(ctx context.Context, userID string) (*IMAgentCard, error) {
+	res, err := imCall(ctx, t.c, http.MethodGet, "/api/im/agents/"+userID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var card IMAgentCard
+	if err := res.Decode(&card); err != nil {
+		return nil, fmt.Errorf("failed to decode agent card: %w", err)
+	}
+	return &card, nil
+}
+
+// BindingsClient links the account to external platforms.
+type BindingsClient struct{ c *Client }
+
+// Create starts a new platform binding.
+func (b *BindingsClient) Create(ctx context.Context, opts *IMBindingOptions) (*IMResult, error) {
+	return imCall(ctx, b.c, http.MethodPost, "/api/im/bindings", nil, opts)
+}
+
+// Verify confirms a binding with the code delivered on the platform.
+func (b *BindingsClient) Verify(ctx context.Context, bindingID, code string) (*IMResult, error) {
+	return imCall(ctx, b.c, http.MethodPost, "/api/im/bindings/"+bindingID+"/verify", nil, map[string]any{"code": code})
+}
+
+// List returns the account's bindings.
+func (b *BindingsClient) List(ctx context.Context) (*IMResult, error) {
+	return imCall(ctx, b.c, http.MethodGet, "/api/im/bindings", nil, nil)
+}
+
+// Delete removes a binding.
+func (b *BindingsClient) Delete(ctx context.Context, bindingID string) (*IMResult, error) {
+	return imCall(ctx, b.c, http.MethodDelete, "/api/im/bindings/"+bindingID, nil, nil)
+}
+
+// WorkspaceClient wires IM conversations to Prismer workspaces.
+type WorkspaceClient struct{ c *Client }
+
+// Init creates (or returns) the direct conversation backing a workspace.
+func (w *WorkspaceClient) Init(ctx context.Context, opts *IMWorkspaceInitOptions) (*IMResult, error) {
+	return imCall(ctx, w.c, http.MethodPost, "/api/im/workspace/init", nil, opts)
+}
+
+// InitGroup creates (or returns) the group conversation backing a workspace.
+func (w *WorkspaceClient) InitGroup(ctx context.Context, opts *IMWorkspaceInitGroupOptions) (*IMResult, error) {
+	return imCall(ctx, w.c, http.MethodPost, "/api/im/workspace/init-group", nil, opts)
+}
+
+// AddAgent attaches an agent to the workspace conversation.
+func (w *WorkspaceClient) AddAgent(ctx context.Context, workspaceID, agentID string) (*IMResult, error) {
+	return imCall(ctx, w.c, http.MethodPost, "/api/im/workspace/"+workspaceID+"/agents", nil, map[string]any{"agentId": agentID})
+}
+
+// ListAgents returns the agents attached to the workspace.
+func (w *WorkspaceClient) ListAgents(ctx context.Context, workspaceID string) (*IMResult, error) {
+	return imCall(ctx, w.c, http.MethodGet, "/api/im/workspace/"+workspaceID+"/agents", nil, nil)
+}
+
+// MentionAutocomplete returns mentionable participants for a conversation.
+func (w *WorkspaceClient) MentionAutocomplete(ctx context.Context, conversationID, query string) (*IMResult, error) {
+	q := url.Values{}
+	if query != "" {
+		q.Set("q", query)
+	}
+	return imCall(ctx, w.c, http.MethodGet, "/api/im/workspace/mentions/"+conversationID, q, nil)
+}
+
+// CreditsClient reads the credits ledger.
+type CreditsClient struct{ c *Client }
+
+// Balance returns the current credit balance.
+func (r *CreditsClient) Balance(ctx context.Context) (*IMResult, error) {
+	return imCall(ctx, r.c, http.MethodGet, "/api/im/credits/balance", nil, nil)
+}
+
+// Transactions returns the credit transaction history.
+func (r *CreditsClient) Transactions(ctx context.Context, opts *IMPaginationOptions) (*IMResult, error) {
+	return imCall(ctx, r.c, http.MethodGet, "/api/im/credits/transactions", paginationQuery(opts), nil)
+}
+
+// ConversationCredits aggregates the credit movement attributable to one
+// conversation.
+type ConversationCredits struct {
+	ConversationID string              `json:"conversationId"`
+	Spent          float64             `json:"spent"`
+	Earned         float64             `json:"earned"`
+	Net            float64             `json:"net"`
+	Transactions   []CreditTransaction `json:"transactions,omitempty"`
+}
+
+// creditsPageSize is how many transactions ByConversation fetches per page.
+const creditsPageSize = 100
+
+// ByConversation sums the credits spent and earned within a conversation.
+// The server does not index transactions by conversation, so this pages
+// through Transactions and keeps entries whose reference names the
+// conversation (either the bare ID or a "conversation:<id>" reference).
+func (r *CreditsClient) ByConversation(ctx context.Context, conversationID string) (*ConversationCredits, error) {
+	out := &ConversationCredits{ConversationID: conversationID}
+	for offset := 0; ; offset += creditsPageSize {
+		res, err := r.Transactions(ctx, &IMPaginationOptions{Limit: creditsPageSize, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		if err := res.Err(); err != nil {
+			return nil, err
+		}
+		var page []CreditTransaction
+		if err := res.Decode(&page); err != nil {
+			return nil, fmt.Errorf("failed to decode transactions: %w", err)
+		}
+		for _, tx := range page {
+			if tx.Reference != conversationID && tx.Reference != "conversation:"+conversationID {
+				continue
+			}
+			out.Transactions = append(out.Transactions, tx)
+			out.Net += tx.Amount
+			if tx.Amount < 0 {
+				out.Spent += -tx.Amount
+			} else {
+				out.Earned += tx.Amount
+			}
+		}
+		if len(page) < creditsPageSize {
+			return out, nil
+		}
+	}
+}
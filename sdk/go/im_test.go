@@ -0,0 +1,275 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSendMessageWithQuoteEmbedsSnippet(t *testing.T) {
+	var sentBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/im/messages/msg-1":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success": true,
+				"data": map[string]any{
+					"id":             "msg-1",
+					"conversationId": "conv-1",
+					"senderId":       "user-2",
+					"content":        strings.Repeat("x", 300),
+				},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/im/direct/user-2/messages":
+			raw, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(raw, &sentBody)
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": true})
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithBaseURL(srv.URL))
+	res, err := client.IM().Direct.SendMessage(context.Background(), "user-2", "reply", &IMSendOptions{
+		QuotedMessageID: "msg-1",
+	})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if !res.OK() {
+		t.Fatalf("send not OK: %+v", res.Error)
+	}
+
+	if got := sentBody["quotedMessageId"]; got != "msg-1" {
+		t.Errorf("quotedMessageId = %v, want msg-1", got)
+	}
+	meta, _ := sentBody["metadata"].(map[string]any)
+	if meta == nil {
+		t.Fatal("send carried no metadata")
+	}
+	quote, _ := meta["_quote"].(map[string]any)
+	if quote == nil {
+		t.Fatal("metadata carried no _quote")
+	}
+	if quote["senderId"] != "user-2" {
+		t.Errorf("_quote.senderId = %v, want user-2", quote["senderId"])
+	}
+	snippet, _ := quote["snippet"].(string)
+	if !strings.HasPrefix(snippet, "xxx") || len([]rune(snippet)) != quoteSnippetLen+1 {
+		t.Errorf("snippet not truncated to %d runes: %d", quoteSnippetLen, len([]rune(snippet)))
+	}
+}
+
+func TestFindByCapabilityRoundRobinSkipsOffline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("capability") != "parse_pdf" {
+			t.Errorf("capability filter not forwarded: %s", r.URL.RawQuery)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"data": []map[string]any{
+				{"userId": "a1", "username": "alpha", "status": "online"},
+				{"userId": "a2", "username": "beta", "status": "offline"},
+				{"userId": "a3", "username": "gamma", "status": "online"},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	opts := &SelectOptions{Strategy: SelectRoundRobin}
+
+	var picked []string
+	for i := 0; i < 3; i++ {
+		agent, err := client.IM().Contacts.FindByCapability(context.Background(), "parse_pdf", opts)
+		if err != nil {
+			t.Fatalf("FindByCapability: %v", err)
+		}
+		picked = append(picked, agent.UserID)
+	}
+	if picked[0] != "a1" || picked[1] != "a3" || picked[2] != "a1" {
+		t.Fatalf("round-robin order = %v", picked)
+	}
+}
+
+func TestWaitForMessagePollsUntilMatch(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/im/direct/u1/messages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		n := atomic.AddInt32(&calls, 1)
+		msgs := []map[string]any{{"id": "m1", "content": "earlier"}}
+		if n >= 2 {
+			msgs = append(msgs, map[string]any{"id": "m2", "content": "the answer"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "data": msgs})
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msg, err := client.IM().Direct.WaitForMessage(ctx, "u1", func(m IMMessage) bool {
+		return m.Content == "the answer"
+	})
+	if err != nil {
+		t.Fatalf("WaitForMessage: %v", err)
+	}
+	if msg.ID != "m2" {
+		t.Fatalf("matched message %q, want m2", msg.ID)
+	}
+	if atomic.LoadInt32(&calls) < 2 {
+		t.Fatalf("expected at least 2 polls, got %d", calls)
+	}
+}
+
+func TestWaitForMessageHonorsContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "data": []any{}})
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.IM().Direct.WaitForMessage(ctx, "u1", func(IMMessage) bool { return false }); err == nil {
+		t.Fatal("expected context deadline error")
+	}
+}
+
+func TestNextCursorFromBodyAndHeaders(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		header map[string]string
+		want   string
+	}{
+		{
+			name: "body cursor",
+			body: `{"success":true,"data":[],"nextCursor":"from-body"}`,
+			want: "from-body",
+		},
+		{
+			name:   "x-next-cursor header",
+			body:   `{"success":true,"data":[]}`,
+			header: map[string]string{"X-Next-Cursor": "from-header"},
+			want:   "from-header",
+		},
+		{
+			name:   "link header",
+			body:   `{"success":true,"data":[]}`,
+			header: map[string]string{"Link": `</api/im/groups?cursor=from-link>; rel="next"`},
+			want:   "from-link",
+		},
+		{
+			name:   "body wins over header",
+			body:   `{"success":true,"data":[],"nextCursor":"from-body"}`,
+			header: map[string]string{"X-Next-Cursor": "from-header"},
+			want:   "from-body",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tc.header {
+					w.Header().Set(k, v)
+				}
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			client := NewClient("k", WithBaseURL(srv.URL))
+			res, err := client.IM().Groups.List(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if res.NextCursor != tc.want {
+				t.Fatalf("NextCursor = %q, want %q", res.NextCursor, tc.want)
+			}
+		})
+	}
+}
+
+func TestTypedMessagesDecodesHistoryDirectly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"data":[
+			{"id":"m1","conversationId":"c1","senderId":"u1","content":"hi","metadata":"{\"lang\":\"en\"}"},
+			{"id":"m2","conversationId":"c1","senderId":"u2","content":"yo"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	msgs, err := client.IM().Direct.Messages(context.Background(), "u1", nil)
+	if err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].ID != "m1" {
+		t.Fatalf("msgs = %+v, want two decoded messages", msgs)
+	}
+	if got := msgs[0].MetadataMap(); got["lang"] != "en" {
+		t.Fatalf("metadata = %v, string-typed metadata not normalized", got)
+	}
+}
+
+func TestDiscoverForwardsQueryAndPaging(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/im/discover" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("q") != "math" || q.Get("limit") != "25" || q.Get("offset") != "50" {
+			t.Errorf("paging params not forwarded: %s", r.URL.RawQuery)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "data": []any{}})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithBaseURL(srv.URL))
+	res, err := client.IM().Contacts.Discover(context.Background(), &IMDiscoverOptions{
+		Query: "math", Limit: 25, Offset: 50,
+	})
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if !res.OK() {
+		t.Fatalf("discover not OK: %+v", res.Error)
+	}
+}
+
+func TestSendRejectsUnknownMessageType(t *testing.T) {
+	// No server: the typo must be caught before any request is built.
+	client := NewClient("k", WithBaseURL("http://127.0.0.1:0"))
+	_, err := client.IM().Direct.SendMessage(context.Background(), "u2", "hi", &IMSendOptions{
+		Type: "markdwon",
+	})
+	if !errors.Is(err, ErrInvalidMessageType) {
+		t.Fatalf("expected ErrInvalidMessageType, got %v", err)
+	}
+
+	for _, typ := range []MessageType{"", MessageTypeText, MessageTypeMarkdown, MessageTypeToolCall,
+		MessageTypeToolResult, MessageTypeThinking, MessageTypeImage, MessageTypeFile} {
+		if !typ.Valid() {
+			t.Errorf("MessageType(%q).Valid() = false, want true", typ)
+		}
+	}
+
+	defer func() { ValidateMessageTypes = true }()
+	ValidateMessageTypes = false
+	_, err = client.IM().Direct.SendMessage(context.Background(), "u2", "hi", &IMSendOptions{
+		Type: "custom_type",
+	})
+	if errors.Is(err, ErrInvalidMessageType) {
+		t.Fatalf("validation not overridable: %v", err)
+	}
+}
@@ -0,0 +1,1132 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Offline event names emitted by OfflineManager. Subscribe with the typed
+// On* methods, or with On using these names; each event carries the
+// payload struct of the matching *Event type (MessageConfirmedEvent,
+// OutboxFailedEvent, SyncCompleteEvent, ...).
+const (
+	EventMessageConfirmed = "message.confirmed"
+	EventOutboxFailed     = "outbox.failed"
+	EventSyncComplete     = "sync.complete"
+	EventNetworkOnline    = "network.online"
+	EventNetworkOffline   = "network.offline"
+	EventPrewarmProgress  = "prewarm.progress"
+	EventSyncGap          = "sync.gap"
+	EventSyncConflict     = "sync.conflict"
+)
+
+// Conflict strategies for applying sync events over local state.
+const (
+	ConflictServer = "server"
+	ConflictClient = "client"
+)
+
+const (
+	defaultFlushInterval    = time.Second
+	defaultMaxRetries       = 5
+	defaultCacheMaxMessages = 200
+	maxFlushBatch           = 10
+	defaultSyncLimit        = 100
+
+	defaultPrewarmConversations = 20
+	defaultPrewarmMessages      = 50
+
+	// Connectivity probing: request timeout per probe and the cap on the
+	// offline backoff, as a multiple of the configured interval.
+	defaultProbeInterval = 30 * time.Second
+	probeTimeout         = 5 * time.Second
+	probeBackoffFactor   = 8
+)
+
+// defaultRetryableErrorCodes is the legacy classification: server errors
+// whose code contains one of these substrings are retried, everything else
+// fails the operation permanently.
+var defaultRetryableErrorCodes = []string{"TIMEOUT", "NETWORK"}
+
+// OfflineOptions configures an OfflineManager.
+type OfflineOptions struct {
+	// FlushInterval is how often the outbox is flushed while online.
+	// Defaults to one second.
+	FlushInterval time.Duration
+
+	// MaxRetries is how many delivery attempts an outbox operation gets
+	// before it is marked failed. Defaults to 5.
+	MaxRetries int
+
+	// ConflictStrategy controls how sync events interact with local
+	// changes: ConflictServer (default) or ConflictClient.
+	ConflictStrategy string
+
+	// CacheMaxMessages bounds how many messages are kept per conversation.
+	// Defaults to 200.
+	CacheMaxMessages int
+
+	// RetryableErrorCodes lists server error codes (matched exactly) that
+	// mark a flush failure as retryable. When empty, the legacy default
+	// applies: codes containing "TIMEOUT" or "NETWORK" are retryable.
+	RetryableErrorCodes []string
+
+	// RetryClassifier, when set, decides retryability of a server error
+	// and takes precedence over RetryableErrorCodes. Transport errors
+	// (the request never got a response) are always retryable.
+	RetryClassifier func(*APIError) bool
+
+	// PrewarmOnInit populates the cache from the server during Init so
+	// the app has usable data if it goes offline immediately.
+	PrewarmOnInit bool
+
+	// PrewarmConversations bounds how many recent conversations Prewarm
+	// fetches. Defaults to 20.
+	PrewarmConversations int
+
+	// PrewarmMessages bounds how many recent messages Prewarm fetches
+	// per conversation. Defaults to 50.
+	PrewarmMessages int
+
+	// ResyncOnGap makes Sync restart from an empty cursor (a full
+	// re-fetch) when the server's event sequence shows a discontinuity.
+	// A sync.gap event is emitted either way.
+	ResyncOnGap bool
+
+	// ConnectivityProbePath, when set (e.g. "/api/im/health"), enables
+	// automatic connectivity detection: the manager periodically probes
+	// the path and flips the online state itself, so callers no longer
+	// need to invoke SetOnline by hand. Recovery triggers the usual
+	// flush and sync; transitions emit network.online/network.offline.
+	ConnectivityProbePath string
+
+	// ConnectivityProbeInterval is the time between probes while online.
+	// While offline the interval backs off exponentially up to 8x.
+	// Defaults to 30 seconds.
+	ConnectivityProbeInterval time.Duration
+
+	// Storage is the persistence backend for the cache and outbox. When
+	// nil a fresh MemoryStorage is used.
+	Storage Storage
+}
+
+// OfflineHandler observes offline events. The payload shape depends on the
+// event; see the Event* constants and their payload structs.
+type OfflineHandler func(event string, payload any)
+
+// MessageConfirmedEvent is the payload of message.confirmed: a queued send
+// was accepted and the optimistic copy replaced by the server's message.
+type MessageConfirmedEvent struct {
+	ClientID string
+	Message  *StoredMessage
+}
+
+// OutboxFailedEvent is the payload of outbox.failed.
+type OutboxFailedEvent struct {
+	OpID  string
+	Error string
+
+	// Op is a copy of the failed operation, payload included, so callers
+	// can persist it or retry later via RetryFailed.
+	Op *OutboxOp
+}
+
+// SyncCompleteEvent is the payload of sync.complete.
+type SyncCompleteEvent struct {
+	NewMessages          int
+	UpdatedConversations int
+}
+
+// SyncGapEvent is the payload of sync.gap: the server's event sequence
+// skipped ahead, so events were dropped.
+type SyncGapEvent struct {
+	ExpectedSeq int64
+	GotSeq      int64
+}
+
+// SyncConflictEvent is the payload of sync.conflict: under the "client"
+// strategy a server event was skipped because it would have clobbered an
+// unconfirmed local change.
+type SyncConflictEvent struct {
+	// Kind is "message" or "conversation".
+	Kind string
+	// ID identifies the kept local record.
+	ID string
+	// RemoteSeq is the sequence number of the skipped server event.
+	RemoteSeq int64
+}
+
+// PrewarmProgressEvent is the payload of prewarm.progress.
+type PrewarmProgressEvent struct {
+	Done           int
+	Total          int
+	ConversationID string
+}
+
+// NetworkChangeEvent is the payload of network.online and network.offline.
+type NetworkChangeEvent struct {
+	Online bool
+}
+
+// OfflineManager layers an offline-first cache and write outbox over a
+// Client. Reads are served from the network when online and from the cache
+// when not; writes made while offline are queued and flushed once
+// connectivity returns.
+type OfflineManager struct {
+	doer    requestDoer
+	opts    OfflineOptions
+	storage Storage
+	emitter *offlineEmitter
+	decrypt MessageDecryptor
+	clk     clock
+
+	mu         sync.RWMutex
+	online     bool
+	pathToConv map[string]string
+	lastSeq    int64
+
+	stopCh  chan struct{}
+	started bool
+	wg      sync.WaitGroup
+}
+
+// shutdownTimeout bounds how long Close waits for background goroutines.
+const shutdownTimeout = 5 * time.Second
+
+// NewOfflineManager creates a manager over client. opts may be nil.
+func NewOfflineManager(client *Client, opts *OfflineOptions) *OfflineManager {
+	m := newOfflineManager(client, opts)
+	m.decrypt = client.decryptor
+	m.emitter.onPanic = client.panicHandler
+	client.registerCloser(m)
+	return m
+}
+
+// newOfflineManager builds a manager over any request doer. Tests use it
+// to inject a fake transport.
+func newOfflineManager(doer requestDoer, opts *OfflineOptions) *OfflineManager {
+	o := OfflineOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = defaultFlushInterval
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = defaultMaxRetries
+	}
+	if o.ConflictStrategy == "" {
+		o.ConflictStrategy = ConflictServer
+	}
+	if o.CacheMaxMessages <= 0 {
+		o.CacheMaxMessages = defaultCacheMaxMessages
+	}
+	if o.PrewarmConversations <= 0 {
+		o.PrewarmConversations = defaultPrewarmConversations
+	}
+	if o.PrewarmMessages <= 0 {
+		o.PrewarmMessages = defaultPrewarmMessages
+	}
+	if o.ConnectivityProbeInterval <= 0 {
+		o.ConnectivityProbeInterval = defaultProbeInterval
+	}
+	if o.Storage == nil {
+		o.Storage = NewMemoryStorage()
+	}
+	return &OfflineManager{
+		doer:       doer,
+		opts:       o,
+		storage:    o.Storage,
+		emitter:    newOfflineEmitter(nil),
+		clk:        realClock{},
+		online:     true,
+		pathToConv: make(map[string]string),
+	}
+}
+
+// setClock swaps the time source; tests use it for deterministic
+// timestamps and waits.
+func (m *OfflineManager) setClock(c clock) { m.clk = c }
+
+// Storage exposes the underlying backend, e.g. for direct reads in tests.
+func (m *OfflineManager) Storage() Storage { return m.storage }
+
+// On registers a handler for an offline event name. The typed On* methods
+// below are preferred; On remains for events without a dedicated hook.
+func (m *OfflineManager) On(event string, handler OfflineHandler) {
+	m.emitter.on(event, handler)
+}
+
+// OnMessageConfirmed registers a typed handler for message.confirmed.
+func (m *OfflineManager) OnMessageConfirmed(h func(MessageConfirmedEvent)) {
+	m.emitter.on(EventMessageConfirmed, func(_ string, payload any) {
+		if ev, ok := payload.(MessageConfirmedEvent); ok {
+			h(ev)
+		}
+	})
+}
+
+// OnOutboxFailed registers a typed handler for outbox.failed.
+func (m *OfflineManager) OnOutboxFailed(h func(OutboxFailedEvent)) {
+	m.emitter.on(EventOutboxFailed, func(_ string, payload any) {
+		if ev, ok := payload.(OutboxFailedEvent); ok {
+			h(ev)
+		}
+	})
+}
+
+// OnSyncComplete registers a typed handler for sync.complete.
+func (m *OfflineManager) OnSyncComplete(h func(SyncCompleteEvent)) {
+	m.emitter.on(EventSyncComplete, func(_ string, payload any) {
+		if ev, ok := payload.(SyncCompleteEvent); ok {
+			h(ev)
+		}
+	})
+}
+
+// OnSyncGap registers a typed handler for sync.gap.
+func (m *OfflineManager) OnSyncGap(h func(SyncGapEvent)) {
+	m.emitter.on(EventSyncGap, func(_ string, payload any) {
+		if ev, ok := payload.(SyncGapEvent); ok {
+			h(ev)
+		}
+	})
+}
+
+// OnSyncConflict registers a typed handler for sync.conflict.
+func (m *OfflineManager) OnSyncConflict(h func(SyncConflictEvent)) {
+	m.emitter.on(EventSyncConflict, func(_ string, payload any) {
+		if ev, ok := payload.(SyncConflictEvent); ok {
+			h(ev)
+		}
+	})
+}
+
+// OnPrewarmProgress registers a typed handler for prewarm.progress.
+func (m *OfflineManager) OnPrewarmProgress(h func(PrewarmProgressEvent)) {
+	m.emitter.on(EventPrewarmProgress, func(_ string, payload any) {
+		if ev, ok := payload.(PrewarmProgressEvent); ok {
+			h(ev)
+		}
+	})
+}
+
+// OnNetworkChange registers a typed handler covering both network.online
+// and network.offline.
+func (m *OfflineManager) OnNetworkChange(h func(NetworkChangeEvent)) {
+	forward := func(_ string, payload any) {
+		if ev, ok := payload.(NetworkChangeEvent); ok {
+			h(ev)
+		}
+	}
+	m.emitter.on(EventNetworkOnline, forward)
+	m.emitter.on(EventNetworkOffline, forward)
+}
+
+// Init starts the background flush loop and runs an initial sync when
+// online. It must be called once before Dispatch.
+func (m *OfflineManager) Init(ctx context.Context) error {
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return errors.New("prismer: offline manager already initialized")
+	}
+	m.started = true
+	m.stopCh = make(chan struct{})
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.flushLoop()
+	}()
+	if m.opts.ConnectivityProbePath != "" {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.probeLoop()
+		}()
+	}
+
+	if m.isOnline() {
+		if err := m.Sync(ctx); err != nil {
+			return fmt.Errorf("initial sync failed: %w", err)
+		}
+		if m.opts.PrewarmOnInit {
+			if err := m.Prewarm(ctx); err != nil {
+				return fmt.Errorf("prewarm failed: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Prewarm fetches the most recent conversations and their latest messages
+// into the cache so reads keep working after a loss of connectivity. The
+// amount of data fetched is bounded by PrewarmConversations and
+// PrewarmMessages. A prewarm.progress event is emitted per conversation.
+func (m *OfflineManager) Prewarm(ctx context.Context) error {
+	if !m.isOnline() {
+		return errors.New("prismer: cannot prewarm while offline")
+	}
+
+	res, err := imCall(ctx, m.doer, http.MethodGet, "/api/im/conversations",
+		paginationQuery(&IMPaginationOptions{Limit: m.opts.PrewarmConversations}), nil)
+	if err != nil {
+		return err
+	}
+	if err := res.Err(); err != nil {
+		return err
+	}
+	var convs []IMConversation
+	if err := res.Decode(&convs); err != nil {
+		return fmt.Errorf("failed to decode conversations: %w", err)
+	}
+
+	for i := range convs {
+		conv := &convs[i]
+		m.storage.PutConversation(&StoredConversation{
+			ID:            conv.ID,
+			Type:          conv.Type,
+			Title:         conv.Title,
+			UnreadCount:   conv.UnreadCount,
+			LastMessageAt: conv.UpdatedAt,
+		})
+
+		msgRes, err := imCall(ctx, m.doer, http.MethodGet, "/api/im/conversations/"+conv.ID+"/messages",
+			paginationQuery(&IMPaginationOptions{Limit: m.opts.PrewarmMessages}), nil)
+		if err != nil {
+			return err
+		}
+		if msgRes.OK() {
+			var msgs []IMMessage
+			if err := msgRes.Decode(&msgs); err == nil {
+				stored := make([]*StoredMessage, 0, len(msgs))
+				for j := range msgs {
+					decryptIMMessage(m.decrypt, &msgs[j])
+					stored = append(stored, storedFromIMMessage(&msgs[j]))
+				}
+				m.storage.PutMessages(stored)
+			}
+		}
+
+		m.emitter.emit(EventPrewarmProgress, PrewarmProgressEvent{
+			Done:           i + 1,
+			Total:          len(convs),
+			ConversationID: conv.ID,
+		})
+	}
+	return nil
+}
+
+// Destroy stops background work. The cache remains readable.
+func (m *OfflineManager) Destroy() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.started {
+		return
+	}
+	m.started = false
+	close(m.stopCh)
+}
+
+// Close implements io.Closer: it stops background work and waits (bounded
+// by shutdownTimeout) for the manager's goroutines to exit, so tests and
+// short-lived processes don't leak them.
+func (m *OfflineManager) Close() error {
+	m.Destroy()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-m.clk.After(shutdownTimeout):
+		return errors.New("prismer: offline manager goroutines did not exit within timeout")
+	}
+}
+
+// SetOnline flips connectivity state. Going online triggers a flush and a
+// sync in the background.
+func (m *OfflineManager) SetOnline(online bool) {
+	m.mu.Lock()
+	changed := m.online != online
+	m.online = online
+	m.mu.Unlock()
+	if !changed {
+		return
+	}
+	if online {
+		m.emitter.emit(EventNetworkOnline, NetworkChangeEvent{Online: true})
+		go func() {
+			m.Flush(context.Background())
+			_ = m.Sync(context.Background())
+		}()
+	} else {
+		m.emitter.emit(EventNetworkOffline, NetworkChangeEvent{Online: false})
+	}
+}
+
+// DeadLettered returns the outbox operations that failed permanently,
+// oldest first. They are kept until requeued so a failed write can be
+// inspected and recovered instead of being silently dropped.
+func (m *OfflineManager) DeadLettered() []*OutboxOp {
+	return m.storage.DeadLettered()
+}
+
+// RetryFailed puts one dead-lettered operation back into rotation with a
+// fresh retry budget and, when online, kicks off a flush. It reports
+// whether opID named a failed op.
+func (m *OfflineManager) RetryFailed(opID string) bool {
+	if !m.storage.Requeue(opID) {
+		return false
+	}
+	if m.isOnline() {
+		go m.Flush(context.Background())
+	}
+	return true
+}
+
+// RetryAllFailed requeues every dead-lettered operation and returns how
+// many were put back into rotation.
+func (m *OfflineManager) RetryAllFailed() int {
+	requeued := 0
+	for _, op := range m.storage.DeadLettered() {
+		if m.storage.Requeue(op.ID) {
+			requeued++
+		}
+	}
+	if requeued > 0 && m.isOnline() {
+		go m.Flush(context.Background())
+	}
+	return requeued
+}
+
+func (m *OfflineManager) isOnline() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.online
+}
+
+// Dispatch routes an IM request through the offline layer. Reads fall back
+// to the cache when offline; writes made offline are queued in the outbox
+// and applied optimistically to the cache.
+func (m *OfflineManager) Dispatch(ctx context.Context, method, path string, query url.Values, payload map[string]any) (*IMResult, error) {
+	if method == http.MethodGet {
+		if !m.isOnline() {
+			return m.readFromCache(path, query)
+		}
+		res, err := imCall(ctx, m.doer, method, path, query, nil)
+		if err != nil {
+			return m.readFromCache(path, query)
+		}
+		m.cacheReadResult(path, res)
+		return res, nil
+	}
+
+	if !m.isOnline() {
+		return m.dispatchWrite(method, path, payload)
+	}
+	return imCall(ctx, m.doer, method, path, query, payload)
+}
+
+// dispatchWrite queues a write for later delivery and returns an optimistic
+// result mirroring what the server would send.
+func (m *OfflineManager) dispatchWrite(method, path string, payload map[string]any) (*IMResult, error) {
+	opType := opTypeForRequest(method, path)
+	clientID, _ := payload["clientId"].(string)
+	if clientID == "" {
+		clientID = generateID("client")
+	}
+
+	conversationID := m.conversationForPath(path)
+	op := &OutboxOp{
+		ID:             generateID("op"),
+		Type:           opType,
+		Method:         method,
+		Path:           path,
+		Payload:        payload,
+		ConversationID: conversationID,
+		ClientID:       clientID,
+		CreatedAt:      formatRFC3339(m.clk.Now()),
+	}
+	m.storage.Enqueue(op)
+
+	if opType == "message.send" {
+		content, _ := payload["content"].(string)
+		msgType, _ := payload["type"].(string)
+		meta, _ := payload["metadata"].(map[string]any)
+		optimistic := &StoredMessage{
+			ID:             clientID,
+			ClientID:       clientID,
+			ConversationID: conversationID,
+			Content:        content,
+			Type:           msgType,
+			Metadata:       meta,
+			CreatedAt:      formatRFC3339(m.clk.Now()),
+			Pending:        true,
+		}
+		m.storage.PutMessage(optimistic)
+		data, err := json.Marshal(optimistic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode optimistic message: %w", err)
+		}
+		return &IMResult{Success: true, Data: data}, nil
+	}
+
+	return &IMResult{Success: true}, nil
+}
+
+// Flush attempts delivery of pending outbox operations. It is called
+// automatically by the flush loop but may also be invoked directly.
+func (m *OfflineManager) Flush(ctx context.Context) {
+	if !m.isOnline() {
+		return
+	}
+	for _, op := range m.storage.DequeueReady(maxFlushBatch) {
+		m.deliver(ctx, op)
+	}
+}
+
+// deliver sends one outbox operation and settles it against the storage.
+func (m *OfflineManager) deliver(ctx context.Context, op *OutboxOp) {
+	// Every outbox write carries its client ID as an Idempotency-Key
+	// header so a redelivered op of any type is deduplicated server-side.
+	if op.ClientID != "" {
+		ctx = withIdempotencyKey(ctx, op.ClientID)
+	}
+	raw, err := m.doer.doRequest(ctx, op.Method, op.Path, nil, op.Payload)
+	if err != nil {
+		// Transport failures and rate limits (*RateLimitError) are always
+		// retried: the server either never answered or explicitly asked us
+		// to come back later, so the op must not be dropped.
+		m.storage.Nack(op.ID, m.opts.MaxRetries, err.Error())
+		m.failIfExhausted(op, err.Error())
+		return
+	}
+	res, err := decodeJSON[IMResult](raw)
+	if err != nil {
+		m.storage.Nack(op.ID, m.opts.MaxRetries, err.Error())
+		m.failIfExhausted(op, err.Error())
+		return
+	}
+	if !res.OK() {
+		apiErr := res.Error
+		if apiErr == nil {
+			apiErr = &APIError{Code: "UNKNOWN", Message: "request rejected"}
+		}
+		if m.retryable(apiErr) {
+			m.storage.Nack(op.ID, m.opts.MaxRetries, apiErr.Error())
+			m.failIfExhausted(op, apiErr.Error())
+		} else {
+			m.storage.Fail(op.ID, apiErr.Error())
+			cp := *op
+			cp.Status = opStatusFailed
+			cp.LastError = apiErr.Error()
+			m.emitter.emit(EventOutboxFailed, OutboxFailedEvent{
+				OpID:  op.ID,
+				Error: apiErr.Error(),
+				Op:    &cp,
+			})
+		}
+		return
+	}
+
+	m.storage.Ack(op.ID)
+	if op.Type == "message.send" {
+		var confirmed map[string]any
+		if err := res.Decode(&confirmed); err == nil {
+			stored := m.replaceOptimistic(op, confirmed)
+			m.emitter.emit(EventMessageConfirmed, MessageConfirmedEvent{
+				ClientID: op.ClientID,
+				Message:  stored,
+			})
+		}
+	}
+}
+
+// failIfExhausted emits outbox.failed once an operation runs out of
+// retries. op is the flusher's copy, so its retry count is the one from
+// before the Nack that just happened; the stored op has one more.
+func (m *OfflineManager) failIfExhausted(op *OutboxOp, errMsg string) {
+	if op.Retries+1 < m.opts.MaxRetries {
+		return
+	}
+	cp := *op
+	cp.Retries++
+	cp.Status = opStatusFailed
+	cp.LastError = errMsg
+	m.emitter.emit(EventOutboxFailed, OutboxFailedEvent{
+		OpID:  op.ID,
+		Error: errMsg,
+		Op:    &cp,
+	})
+}
+
+// retryable classifies a server error for the outbox: retry or permanent
+// failure. Callers can override the policy via OfflineOptions.
+func (m *OfflineManager) retryable(apiErr *APIError) bool {
+	if m.opts.RetryClassifier != nil {
+		return m.opts.RetryClassifier(apiErr)
+	}
+	if len(m.opts.RetryableErrorCodes) > 0 {
+		for _, code := range m.opts.RetryableErrorCodes {
+			if apiErr.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+	for _, fragment := range defaultRetryableErrorCodes {
+		if strings.Contains(apiErr.Code, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceOptimistic swaps the pending local message for the confirmed
+// server copy, returning the stored result (nil if the response carried
+// no usable message).
+func (m *OfflineManager) replaceOptimistic(op *OutboxOp, confirmed map[string]any) *StoredMessage {
+	m.storage.DeleteMessage(op.ClientID)
+	id, _ := confirmed["id"].(string)
+	if id == "" {
+		return nil
+	}
+	content, _ := confirmed["content"].(string)
+	msgType, _ := confirmed["type"].(string)
+	senderID, _ := confirmed["senderId"].(string)
+	createdAt, _ := confirmed["createdAt"].(string)
+	conversationID, _ := confirmed["conversationId"].(string)
+	if conversationID == "" {
+		conversationID = op.ConversationID
+	} else {
+		m.learnConversation(op.Path, conversationID)
+	}
+	meta, _ := confirmed["metadata"].(map[string]any)
+	stored := &StoredMessage{
+		ID:             id,
+		ClientID:       op.ClientID,
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Content:        content,
+		Type:           msgType,
+		Metadata:       meta,
+		CreatedAt:      createdAt,
+	}
+	m.storage.PutMessage(stored)
+	return stored
+}
+
+// flushLoop drives periodic outbox flushes until Destroy.
+func (m *OfflineManager) flushLoop() {
+	ticker := time.NewTicker(m.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.Flush(context.Background())
+		}
+	}
+}
+
+// probeLoop periodically checks connectivity against the configured probe
+// path and flips the online state through SetOnline, which handles the
+// events and the recovery flush/sync. While offline, probing backs off
+// exponentially so a dead network is not hammered. Runs until Destroy.
+func (m *OfflineManager) probeLoop() {
+	delay := m.opts.ConnectivityProbeInterval
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-m.clk.After(delay):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		_, err := m.doer.doRequest(ctx, http.MethodGet, m.opts.ConnectivityProbePath, nil, nil)
+		cancel()
+		if err == nil {
+			m.SetOnline(true)
+			delay = m.opts.ConnectivityProbeInterval
+			continue
+		}
+		m.SetOnline(false)
+		delay *= 2
+		if max := probeBackoffFactor * m.opts.ConnectivityProbeInterval; delay > max {
+			delay = max
+		}
+	}
+}
+
+// Sync pulls change events from the server until it reports no more,
+// applying them to the cache and advancing the persisted cursor. Sequence
+// discontinuities (dropped events) are surfaced via a sync.gap event and,
+// with ResyncOnGap, trigger one full re-fetch from an empty cursor.
+func (m *OfflineManager) Sync(ctx context.Context) error {
+	var newMessages, updatedConversations int
+	resynced := false
+
+restart:
+	for {
+		res, err := m.syncOnce(ctx, m.storage.GetCursor(), defaultSyncLimit)
+		if err != nil {
+			return err
+		}
+		for i := range res.Events {
+			ev := &res.Events[i]
+			if ev.Seq > 0 {
+				if last := m.lastSeenSeq(); last > 0 && ev.Seq > last+1 {
+					m.emitter.emit(EventSyncGap, SyncGapEvent{
+						ExpectedSeq: last + 1,
+						GotSeq:      ev.Seq,
+					})
+					if m.opts.ResyncOnGap && !resynced {
+						resynced = true
+						m.storage.SetCursor("")
+						m.setLastSeenSeq(0)
+						newMessages, updatedConversations = 0, 0
+						goto restart
+					}
+				}
+				m.setLastSeenSeq(ev.Seq)
+			}
+			msgs, convs := m.applySyncEvent(ev)
+			newMessages += msgs
+			updatedConversations += convs
+		}
+		if res.NextSince != "" {
+			m.storage.SetCursor(res.NextSince)
+		}
+		if !res.HasMore {
+			break
+		}
+	}
+	m.emitter.emit(EventSyncComplete, SyncCompleteEvent{
+		NewMessages:          newMessages,
+		UpdatedConversations: updatedConversations,
+	})
+	return nil
+}
+
+func (m *OfflineManager) lastSeenSeq() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSeq
+}
+
+func (m *OfflineManager) setLastSeenSeq(seq int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastSeq = seq
+}
+
+// syncOnce fetches one page from the sync endpoint.
+func (m *OfflineManager) syncOnce(ctx context.Context, since string, limit int) (*SyncResultData, error) {
+	q := url.Values{}
+	if since != "" {
+		q.Set("since", since)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	res, err := imCall(ctx, m.doer, http.MethodGet, "/api/im/sync", q, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var data SyncResultData
+	if err := res.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode sync result: %w", err)
+	}
+	return &data, nil
+}
+
+// applySyncEvent folds one server event into the cache, reporting how many
+// messages and conversations it touched. Under the "client" conflict
+// strategy, events that would overwrite an unconfirmed local change are
+// skipped and surfaced as sync.conflict instead.
+func (m *OfflineManager) applySyncEvent(ev *SyncEventData) (messages, conversations int) {
+	switch ev.Type {
+	case "message.new", "message.edit":
+		var im IMMessage
+		if err := json.Unmarshal(ev.Data, &im); err != nil || im.ID == "" {
+			return 0, 0
+		}
+		if ev.Type == "message.edit" && m.keepLocalMessage(im.ID) {
+			m.emitter.emit(EventSyncConflict, SyncConflictEvent{
+				Kind:      "message",
+				ID:        im.ID,
+				RemoteSeq: ev.Seq,
+			})
+			return 0, 0
+		}
+		decryptIMMessage(m.decrypt, &im)
+		stored := storedFromIMMessage(&im)
+		stored.SyncSeq = ev.Seq
+		m.storage.PutMessage(stored)
+		return 1, 0
+	case "message.delete":
+		var payload struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(ev.Data, &payload); err != nil || payload.ID == "" {
+			return 0, 0
+		}
+		m.storage.DeleteMessage(payload.ID)
+		return 1, 0
+	case "conversation.create", "conversation.update":
+		var conv StoredConversation
+		if err := json.Unmarshal(ev.Data, &conv); err != nil || conv.ID == "" {
+			return 0, 0
+		}
+		if ev.Type == "conversation.update" && m.keepLocalConversation(conv.ID) {
+			m.emitter.emit(EventSyncConflict, SyncConflictEvent{
+				Kind:      "conversation",
+				ID:        conv.ID,
+				RemoteSeq: ev.Seq,
+			})
+			return 0, 0
+		}
+		conv.SyncSeq = ev.Seq
+		m.storage.PutConversation(&conv)
+		return 0, 1
+	case "message.read":
+		var receipt ReadReceipt
+		if err := json.Unmarshal(ev.Data, &receipt); err != nil || receipt.MessageID == "" {
+			return 0, 0
+		}
+		if msg := m.storage.GetMessage(receipt.MessageID); msg != nil {
+			msg.ReadAt = receipt.ReadAt
+			msg.SyncSeq = ev.Seq
+			m.storage.PutMessage(msg)
+			return 1, 0
+		}
+	case "conversation.read":
+		if ev.ConversationID == "" {
+			return 0, 0
+		}
+		if conv := m.storage.GetConversation(ev.ConversationID); conv != nil {
+			conv.UnreadCount = 0
+			conv.SyncSeq = ev.Seq
+			m.storage.PutConversation(conv)
+			return 0, 1
+		}
+	}
+	return 0, 0
+}
+
+// keepLocalMessage reports whether the "client" strategy protects a
+// message from being overwritten: it is either still pending locally or
+// an outbox op editing it is in flight.
+func (m *OfflineManager) keepLocalMessage(id string) bool {
+	if m.opts.ConflictStrategy != ConflictClient {
+		return false
+	}
+	if local := m.storage.GetMessage(id); local != nil && local.Pending {
+		return true
+	}
+	// Pending, not DequeueReady: an op backing off after a failed
+	// delivery is exactly the one most likely to race the server event,
+	// and DequeueReady would hide it.
+	for _, op := range m.storage.Pending() {
+		if strings.Contains(op.Path, "/messages/"+id) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepLocalConversation reports whether the "client" strategy protects a
+// conversation: an outbox op targeting it is still in flight.
+func (m *OfflineManager) keepLocalConversation(id string) bool {
+	if m.opts.ConflictStrategy != ConflictClient {
+		return false
+	}
+	if m.storage.GetConversation(id) == nil {
+		return false
+	}
+	for _, op := range m.storage.Pending() {
+		if op.ConversationID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchMessages scans the local cache for messages containing query.
+func (m *OfflineManager) SearchMessages(query, conversationID string) []*StoredMessage {
+	return m.storage.SearchMessages(query, conversationID)
+}
+
+// readFromCache serves a GET from local state while offline.
+func (m *OfflineManager) readFromCache(path string, query url.Values) (*IMResult, error) {
+	if strings.HasSuffix(path, "/conversations") {
+		data, err := json.Marshal(m.storage.GetConversations())
+		if err != nil {
+			return nil, err
+		}
+		return &IMResult{Success: true, Data: data}, nil
+	}
+	if strings.HasSuffix(path, "/messages") {
+		limit := 0
+		if v := query.Get("limit"); v != "" {
+			limit, _ = strconv.Atoi(v)
+		}
+		msgs := m.storage.GetMessages(m.conversationForPath(path), limit, query.Get("before"))
+		data, err := json.Marshal(msgs)
+		if err != nil {
+			return nil, err
+		}
+		return &IMResult{Success: true, Data: data}, nil
+	}
+	return nil, fmt.Errorf("prismer: offline and %q is not cacheable", path)
+}
+
+// cacheReadResult folds a successful online read into the cache.
+func (m *OfflineManager) cacheReadResult(path string, res *IMResult) {
+	if !res.OK() || !strings.HasSuffix(path, "/messages") {
+		return
+	}
+	var msgs []IMMessage
+	if err := res.Decode(&msgs); err != nil {
+		return
+	}
+	stored := make([]*StoredMessage, 0, len(msgs))
+	for i := range msgs {
+		decryptIMMessage(m.decrypt, &msgs[i])
+		sm := storedFromIMMessage(&msgs[i])
+		if sm.ConversationID != "" {
+			m.learnConversation(path, sm.ConversationID)
+		}
+		stored = append(stored, sm)
+	}
+	m.storage.PutMessages(stored)
+}
+
+// conversationForPath maps a request path to the cache's conversation key.
+// Server-assigned IDs learned from responses take precedence; otherwise a
+// stable key is derived from the path itself.
+func (m *OfflineManager) conversationForPath(path string) string {
+	m.mu.RLock()
+	id, ok := m.pathToConv[path]
+	m.mu.RUnlock()
+	if ok {
+		return id
+	}
+	return derivedConversationKey(path)
+}
+
+// learnConversation records the server-assigned conversation ID for a path
+// and migrates any messages cached under the derived key.
+func (m *OfflineManager) learnConversation(path, conversationID string) {
+	m.mu.Lock()
+	m.pathToConv[path] = conversationID
+	m.mu.Unlock()
+}
+
+// derivedConversationKey builds a stable local key for paths whose real
+// conversation ID is not yet known, e.g. "direct:u123" or "group:g1".
+func derivedConversationKey(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, p := range parts {
+		if (p == "direct" || p == "groups") && i+1 < len(parts) {
+			kind := "direct"
+			if p == "groups" {
+				kind = "group"
+			}
+			return kind + ":" + parts[i+1]
+		}
+	}
+	return "path:" + path
+}
+
+// opTypeForRequest classifies a write request for outbox bookkeeping.
+func opTypeForRequest(method, path string) string {
+	switch {
+	case strings.HasSuffix(path, "/messages") && method == http.MethodPost:
+		return "message.send"
+	case strings.Contains(path, "/messages/") && method == http.MethodPatch:
+		return "message.edit"
+	case strings.Contains(path, "/messages/") && method == http.MethodDelete:
+		return "message.delete"
+	case strings.Contains(path, "/messages/") && strings.HasSuffix(path, "/read"):
+		return "message.read"
+	case strings.HasSuffix(path, "/read"):
+		return "conversation.read"
+	default:
+		return "request"
+	}
+}
+
+// storedFromIMMessage converts a wire message to its cached form, tolerating
+// both object and stringified metadata.
+func storedFromIMMessage(im *IMMessage) *StoredMessage {
+	var meta map[string]any
+	if len(im.Metadata) > 0 {
+		_ = json.Unmarshal(im.Metadata, &meta)
+	}
+	return &StoredMessage{
+		ID:             im.ID,
+		ConversationID: im.ConversationID,
+		SenderID:       im.SenderID,
+		Content:        im.Content,
+		Type:           im.Type,
+		ParentID:       im.ParentID,
+		Metadata:       meta,
+		CreatedAt:      im.CreatedAt,
+	}
+}
+
+// offlineEmitter fans events out to registered handlers. Handler panics
+// are routed to the configured PanicHandler so a misbehaving observer
+// cannot take down the flush loop.
+type offlineEmitter struct {
+	onPanic  PanicHandler
+	mu       sync.RWMutex
+	handlers map[string][]OfflineHandler
+}
+
+func newOfflineEmitter(onPanic PanicHandler) *offlineEmitter {
+	if onPanic == nil {
+		onPanic = defaultPanicHandler
+	}
+	return &offlineEmitter{onPanic: onPanic, handlers: make(map[string][]OfflineHandler)}
+}
+
+func (e *offlineEmitter) on(event string, h OfflineHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.handlers[event] = append(e.handlers[event], h)
+}
+
+func (e *offlineEmitter) emit(event string, payload any) {
+	e.mu.RLock()
+	handlers := append([]OfflineHandler(nil), e.handlers[event]...)
+	e.mu.RUnlock()
+	for _, h := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					e.onPanic(r, debug.Stack())
+				}
+			}()
+			h(event, payload)
+		}()
+	}
+}
@@ -0,0 +1,402 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDoer scripts doRequest outcomes for offline manager tests.
+type fakeDoer struct {
+	mu    sync.Mutex
+	steps []fakeStep
+	calls int
+}
+
+type fakeStep struct {
+	body string
+	err  error
+}
+
+func (f *fakeDoer) doRequest(ctx context.Context, method, path string, query url.Values, body any) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if len(f.steps) == 0 {
+		return []byte(`{"success":true,"data":{}}`), nil
+	}
+	step := f.steps[0]
+	f.steps = f.steps[1:]
+	if step.err != nil {
+		return nil, step.err
+	}
+	return []byte(step.body), nil
+}
+
+// emptySyncServer answers every sync request with an empty event page.
+func emptySyncServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"data":    map[string]any{"events": []any{}, "hasMore": false},
+		})
+	}))
+}
+
+func TestOfflineManagerCloseStopsBackgroundWork(t *testing.T) {
+	srv := emptySyncServer(t)
+	defer srv.Close()
+
+	m := NewOfflineManager(NewClient("k", WithBaseURL(srv.URL)), &OfflineOptions{
+		FlushInterval: 10 * time.Millisecond,
+	})
+	if err := m.Init(context.Background()); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- m.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return within 1s")
+	}
+
+	// Closing again must be a no-op.
+	if err := m.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func TestConnectivityProbeFlipsOnlineState(t *testing.T) {
+	fake := &fakeDoer{steps: []fakeStep{
+		{err: errors.New("dial tcp: connection refused")},
+		{body: `{"success":true}`},
+	}}
+	m := newOfflineManager(fake, &OfflineOptions{
+		FlushInterval:             time.Hour,
+		ConnectivityProbePath:     "/api/im/health",
+		ConnectivityProbeInterval: 5 * time.Millisecond,
+	})
+	m.SetOnline(false)
+
+	changes := make(chan bool, 8)
+	m.OnNetworkChange(func(ev NetworkChangeEvent) { changes <- ev.Online })
+
+	if err := m.Init(context.Background()); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	defer m.Close()
+
+	// The first probe fails (still offline, no event); the second succeeds
+	// and must flip the manager online.
+	select {
+	case online := <-changes:
+		if !online {
+			t.Fatal("probe emitted network.offline while already offline")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("probe did not bring the manager online")
+	}
+	if !m.isOnline() {
+		t.Fatal("manager still reports offline after successful probe")
+	}
+}
+
+func TestFlushRetriesRetryableErrorThenConfirms(t *testing.T) {
+	fake := &fakeDoer{steps: []fakeStep{
+		{body: `{"success":false,"error":{"code":"TIMEOUT","message":"slow"}}`},
+		{body: `{"success":true,"data":{"id":"m1","conversationId":"c1","content":"hi"}}`},
+	}}
+	m := newOfflineManager(fake, nil)
+
+	var confirmed bool
+	m.On(EventMessageConfirmed, func(event string, payload any) { confirmed = true })
+
+	m.storage.Enqueue(&OutboxOp{
+		ID:       "op1",
+		Type:     "message.send",
+		Method:   http.MethodPost,
+		Path:     "/api/im/direct/u1/messages",
+		Payload:  map[string]any{"content": "hi"},
+		ClientID: "client-1",
+	})
+
+	m.Flush(context.Background())
+	if confirmed {
+		t.Fatal("message confirmed after retryable failure")
+	}
+	if got := m.storage.PendingOps(); got != 1 {
+		t.Fatalf("expected 1 pending op after retryable failure, got %d", got)
+	}
+
+	// Clear the retry backoff so the second flush delivers immediately.
+	ms := m.storage.(*MemoryStorage)
+	ms.mu.Lock()
+	ms.outbox["op1"].NextAttemptAt = time.Time{}
+	ms.mu.Unlock()
+
+	m.Flush(context.Background())
+	if !confirmed {
+		t.Fatal("message not confirmed after successful delivery")
+	}
+	if got := m.storage.PendingOps(); got != 0 {
+		t.Fatalf("expected empty outbox, got %d pending ops", got)
+	}
+}
+
+func TestFlushSerializesOpsPerConversation(t *testing.T) {
+	fake := &fakeDoer{steps: []fakeStep{
+		{body: `{"success":false,"error":{"code":"TIMEOUT","message":"slow"}}`},
+		{body: `{"success":true,"data":{"id":"m1","conversationId":"c1","content":"first"}}`},
+		{body: `{"success":true,"data":{"id":"m2","conversationId":"c1","content":"second"}}`},
+	}}
+	m := newOfflineManager(fake, nil)
+
+	m.storage.Enqueue(&OutboxOp{
+		ID:             "op1",
+		Type:           "message.send",
+		Method:         http.MethodPost,
+		Path:           "/api/im/direct/u1/messages",
+		Payload:        map[string]any{"content": "first"},
+		ConversationID: "c1",
+		ClientID:       "client-1",
+		CreatedAt:      "2026-01-01T00:00:01Z",
+	})
+	m.storage.Enqueue(&OutboxOp{
+		ID:             "op2",
+		Type:           "message.send",
+		Method:         http.MethodPost,
+		Path:           "/api/im/direct/u1/messages",
+		Payload:        map[string]any{"content": "second"},
+		ConversationID: "c1",
+		ClientID:       "client-2",
+		CreatedAt:      "2026-01-01T00:00:02Z",
+	})
+
+	// First flush: only op1 may go out, and it fails retryably. op2 must
+	// stay queued behind it rather than overtaking.
+	m.Flush(context.Background())
+	if fake.calls != 1 {
+		t.Fatalf("first flush made %d requests, want 1 (op2 overtook op1)", fake.calls)
+	}
+	if got := m.storage.PendingOps(); got != 2 {
+		t.Fatalf("expected both ops pending, got %d", got)
+	}
+
+	// Clear op1's backoff; the next flush delivers it while op2 still
+	// waits its turn.
+	ms := m.storage.(*MemoryStorage)
+	ms.mu.Lock()
+	ms.outbox["op1"].NextAttemptAt = time.Time{}
+	ms.mu.Unlock()
+
+	m.Flush(context.Background())
+	if fake.calls != 2 {
+		t.Fatalf("second flush made %d total requests, want 2", fake.calls)
+	}
+	if got := m.storage.PendingOps(); got != 1 {
+		t.Fatalf("expected op2 still pending, got %d", got)
+	}
+
+	m.Flush(context.Background())
+	if fake.calls != 3 {
+		t.Fatalf("third flush made %d total requests, want 3", fake.calls)
+	}
+	if got := m.storage.PendingOps(); got != 0 {
+		t.Fatalf("expected empty outbox, got %d pending ops", got)
+	}
+}
+
+func TestTypedOfflineSubscriptions(t *testing.T) {
+	fake := &fakeDoer{steps: []fakeStep{
+		{body: `{"success":true,"data":{"id":"m1","conversationId":"c1","content":"hi"}}`},
+	}}
+	m := newOfflineManager(fake, nil)
+
+	var confirmed *MessageConfirmedEvent
+	m.OnMessageConfirmed(func(ev MessageConfirmedEvent) { confirmed = &ev })
+
+	m.storage.Enqueue(&OutboxOp{
+		ID:       "op1",
+		Type:     "message.send",
+		Method:   http.MethodPost,
+		Path:     "/api/im/direct/u1/messages",
+		Payload:  map[string]any{"content": "hi"},
+		ClientID: "client-1",
+	})
+	m.Flush(context.Background())
+
+	if confirmed == nil {
+		t.Fatal("OnMessageConfirmed handler not invoked")
+	}
+	if confirmed.ClientID != "client-1" {
+		t.Fatalf("ClientID = %q, want client-1", confirmed.ClientID)
+	}
+	if confirmed.Message == nil || confirmed.Message.ID != "m1" {
+		t.Fatalf("Message = %+v, want server message m1", confirmed.Message)
+	}
+}
+
+func TestFlushFailsPermanentlyOnNonRetryableError(t *testing.T) {
+	fake := &fakeDoer{steps: []fakeStep{
+		{body: `{"success":false,"error":{"code":"FORBIDDEN","message":"nope"}}`},
+	}}
+	m := newOfflineManager(fake, nil)
+
+	var failed bool
+	m.On(EventOutboxFailed, func(event string, payload any) { failed = true })
+
+	m.storage.Enqueue(&OutboxOp{
+		ID:      "op1",
+		Type:    "message.send",
+		Method:  http.MethodPost,
+		Path:    "/api/im/direct/u1/messages",
+		Payload: map[string]any{"content": "hi"},
+	})
+
+	m.Flush(context.Background())
+	if !failed {
+		t.Fatal("expected outbox.failed event")
+	}
+	if got := m.storage.PendingOps(); got != 0 {
+		t.Fatalf("failed op still pending: %d", got)
+	}
+	if fake.calls != 1 {
+		t.Fatalf("expected a single delivery attempt, got %d", fake.calls)
+	}
+}
+
+func TestOutboxDeliverySetsIdempotencyKeyHeader(t *testing.T) {
+	keys := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/im/direct/u1/messages" {
+			keys <- r.Header.Get("Idempotency-Key")
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "data": map[string]any{"id": "m1"}})
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	m := NewOfflineManager(client, &OfflineOptions{FlushInterval: time.Hour})
+
+	m.storage.Enqueue(&OutboxOp{
+		ID:       "op1",
+		Type:     "message.delete",
+		Method:   http.MethodPost,
+		Path:     "/api/im/direct/u1/messages",
+		Payload:  map[string]any{"content": "hi"},
+		ClientID: "client-1",
+	})
+	m.Flush(context.Background())
+
+	select {
+	case key := <-keys:
+		if key != "client-1" {
+			t.Fatalf("Idempotency-Key = %q, want client-1", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("delivery request never reached the server")
+	}
+}
+
+func TestDeadLetteredOpsCanBeRetried(t *testing.T) {
+	fake := &fakeDoer{steps: []fakeStep{
+		{body: `{"success":false,"error":{"code":"FORBIDDEN","message":"nope"}}`},
+		{body: `{"success":true,"data":{"id":"m1","conversationId":"c1","content":"hi"}}`},
+	}}
+	m := newOfflineManager(fake, &OfflineOptions{FlushInterval: time.Hour})
+
+	var failedOp *OutboxOp
+	m.OnOutboxFailed(func(ev OutboxFailedEvent) { failedOp = ev.Op })
+
+	m.storage.Enqueue(&OutboxOp{
+		ID:      "op1",
+		Type:    "message.send",
+		Method:  http.MethodPost,
+		Path:    "/api/im/direct/u1/messages",
+		Payload: map[string]any{"content": "hi"},
+	})
+	m.Flush(context.Background())
+
+	if failedOp == nil || failedOp.Payload["content"] != "hi" {
+		t.Fatalf("outbox.failed did not carry the op payload: %+v", failedOp)
+	}
+	dead := m.DeadLettered()
+	if len(dead) != 1 || dead[0].ID != "op1" || dead[0].Status != opStatusFailed {
+		t.Fatalf("DeadLettered = %+v, want op1 failed", dead)
+	}
+
+	if m.RetryFailed("nope") {
+		t.Fatal("RetryFailed accepted an unknown op ID")
+	}
+	if !m.RetryFailed("op1") {
+		t.Fatal("RetryFailed rejected a dead-lettered op")
+	}
+	// RetryFailed flushes in the background; wait for the delivery.
+	deadline := time.Now().Add(2 * time.Second)
+	for m.storage.PendingOps() != 0 || len(m.DeadLettered()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("requeued op not delivered: %d pending, %d dead",
+				m.storage.PendingOps(), len(m.DeadLettered()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFailIfExhaustedEmitsAfterRetriesRunOut(t *testing.T) {
+	fake := &fakeDoer{steps: []fakeStep{
+		{err: errors.New("dial tcp: connection refused")},
+		{err: errors.New("dial tcp: connection refused")},
+	}}
+	m := newOfflineManager(fake, &OfflineOptions{FlushInterval: time.Hour, MaxRetries: 2})
+
+	var failed []OutboxFailedEvent
+	m.OnOutboxFailed(func(ev OutboxFailedEvent) { failed = append(failed, ev) })
+
+	m.storage.Enqueue(&OutboxOp{
+		ID:      "op1",
+		Type:    "message.send",
+		Method:  http.MethodPost,
+		Path:    "/api/im/direct/u1/messages",
+		Payload: map[string]any{"content": "hi"},
+	})
+
+	m.Flush(context.Background())
+	if len(failed) != 0 {
+		t.Fatalf("outbox.failed emitted after first of two attempts: %+v", failed)
+	}
+
+	ms := m.storage.(*MemoryStorage)
+	ms.mu.Lock()
+	ms.outbox["op1"].NextAttemptAt = time.Time{}
+	ms.mu.Unlock()
+
+	m.Flush(context.Background())
+	if len(failed) != 1 {
+		t.Fatalf("expected one outbox.failed event, got %d", len(failed))
+	}
+	if failed[0].Op == nil || failed[0].Op.Retries != 2 {
+		t.Fatalf("event op = %+v, want retries 2", failed[0].Op)
+	}
+}
+
+func TestPanicHandlerObservesHandlerPanics(t *testing.T) {
+	var recovered any
+	e := newOfflineEmitter(func(r any, stack []byte) { recovered = r })
+	e.on("boom", func(string, any) { panic("handler bug") })
+	e.emit("boom", nil)
+	if recovered != "handler bug" {
+		t.Fatalf("recovered = %v, want handler bug", recovered)
+	}
+}
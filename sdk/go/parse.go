@@ -0,0 +1,293 @@
+package prismer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ParseOptions describes a document parse request. Exactly one of URL or
+// Base64 (with Filename) must be set.
+type ParseOptions struct {
+	URL      string `json:"url,omitempty"`
+	Base64   string `json:"base64,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Mode     string `json:"mode,omitempty"`   // "sync" or "async"
+	Output   string `json:"output,omitempty"` // "markdown" (default) or "json"
+}
+
+// ParseEndpoints are the follow-up URLs of an async parse task.
+type ParseEndpoints struct {
+	Stream string `json:"stream,omitempty"`
+	Status string `json:"status,omitempty"`
+	Result string `json:"result,omitempty"`
+}
+
+// ParseResult is the response of the parse endpoints. Synchronous parses
+// carry the document immediately; async parses return a TaskID to poll.
+type ParseResult struct {
+	Success   bool            `json:"success"`
+	TaskID    string          `json:"taskId,omitempty"`
+	Status    string          `json:"status,omitempty"`
+	Markdown  string          `json:"markdown,omitempty"`
+	Pages     int             `json:"pages,omitempty"`
+	Endpoints *ParseEndpoints `json:"endpoints,omitempty"`
+	Error     *APIError       `json:"error,omitempty"`
+}
+
+// ParseStreamEvent is one incremental event from a parse task's stream
+// endpoint, e.g. a page becoming available or the final completion.
+type ParseStreamEvent struct {
+	Type     string `json:"type"`
+	Page     int    `json:"page,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+	Status   string `json:"status,omitempty"`
+
+	// Data is the raw event payload, for event types the struct fields
+	// don't cover.
+	Data json.RawMessage `json:"-"`
+}
+
+// ParseStream consumes the stream endpoint of an async parse task and
+// emits incremental events as the document is processed, so progress can
+// be rendered live instead of polling for the whole result. The channel
+// is closed when the task completes, the stream ends, or ctx is
+// cancelled.
+func (c *Client) ParseStream(ctx context.Context, taskID string) (<-chan ParseStreamEvent, error) {
+	if c.initErr != nil {
+		return nil, c.initErr
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/parse/"+taskID+"/stream", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+c.token())
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("prismer: parse stream returned %s", resp.Status)
+	}
+
+	events := make(chan ParseStreamEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		eventType := ""
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+				var ev ParseStreamEvent
+				_ = json.Unmarshal([]byte(data), &ev)
+				if eventType != "" {
+					ev.Type = eventType
+				}
+				ev.Data = json.RawMessage(data)
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			case line == "":
+				eventType = ""
+			}
+		}
+	}()
+	return events, nil
+}
+
+// ParsePDF submits a document for parsing.
+func (c *Client) ParsePDF(ctx context.Context, opts *ParseOptions) (*ParseResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/parse", nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[ParseResult](raw)
+}
+
+// ParseMaxFileSize bounds how large a file ParseFile will base64-encode.
+// Encoding inflates the payload by a third, so this guards against
+// accidentally buffering huge documents in memory.
+var ParseMaxFileSize int64 = 50 << 20 // 50 MiB
+
+// ParseFile reads a local file, base64-encodes it and submits it for
+// parsing, deriving Filename from the path. mode may be empty for the
+// server default.
+func (c *Client) ParseFile(ctx context.Context, filePath string, mode string) (*ParseResult, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > ParseMaxFileSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes, exceeding the %d byte parse limit", ErrFileTooLarge, filePath, info.Size(), ParseMaxFileSize)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return c.ParsePDF(ctx, &ParseOptions{
+		Base64:   base64.StdEncoding.EncodeToString(data),
+		Filename: filepath.Base(filePath),
+		Mode:     mode,
+	})
+}
+
+// ParseStatus polls the status of an async parse task.
+func (c *Client) ParseStatus(ctx context.Context, taskID string) (*ParseResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodGet, "/api/parse/"+taskID+"/status", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[ParseResult](raw)
+}
+
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultPollTimeout  = 5 * time.Minute
+
+	// parseCancelTimeout bounds the best-effort cancel request issued when
+	// a wait is abandoned, which cannot reuse the already-cancelled ctx.
+	parseCancelTimeout = 5 * time.Second
+)
+
+// ParseCancel aborts an in-flight async parse task so it stops consuming
+// (billable) processing, returning the task's terminal status.
+func (c *Client) ParseCancel(ctx context.Context, taskID string) (*ParseResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodPost, "/api/parse/"+taskID+"/cancel", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[ParseResult](raw)
+}
+
+// PollOptions tunes how ParseAndWait polls an async task.
+type PollOptions struct {
+	// Interval between status polls. Defaults to 2 seconds.
+	Interval time.Duration
+	// Timeout bounds the total wait. Defaults to 5 minutes.
+	Timeout time.Duration
+}
+
+// ParseTaskFailedError reports an async parse task that ended in a failed
+// status, as opposed to a transport or timeout problem.
+type ParseTaskFailedError struct {
+	TaskID string
+	Err    *APIError
+}
+
+func (e *ParseTaskFailedError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("prismer: parse task %s failed: %v", e.TaskID, e.Err)
+	}
+	return fmt.Sprintf("prismer: parse task %s failed", e.TaskID)
+}
+
+// ParseAndWait submits a parse and, when the server answers asynchronously,
+// polls the task to completion and fetches the final document. A
+// synchronous response is returned as-is. Cancellation of ctx stops the
+// polling between attempts.
+func (c *Client) ParseAndWait(ctx context.Context, opts *ParseOptions, poll *PollOptions) (*ParseResult, error) {
+	p := PollOptions{}
+	if poll != nil {
+		p = *poll
+	}
+	if p.Interval <= 0 {
+		p.Interval = defaultPollInterval
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = defaultPollTimeout
+	}
+
+	res, err := c.ParsePDF(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if res.TaskID == "" {
+		// Synchronous mode: the document is already here.
+		return res, nil
+	}
+	return c.WaitForParseTask(ctx, res.TaskID, &p)
+}
+
+// WaitForParseTask polls an already-submitted async parse task until it
+// completes and fetches the final document. Cancelling ctx stops the
+// polling and also issues a best-effort ParseCancel so the abandoned task
+// does not keep running server-side.
+func (c *Client) WaitForParseTask(ctx context.Context, taskID string, poll *PollOptions) (*ParseResult, error) {
+	p := PollOptions{}
+	if poll != nil {
+		p = *poll
+	}
+	if p.Interval <= 0 {
+		p.Interval = defaultPollInterval
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = defaultPollTimeout
+	}
+
+	deadline := time.Now().Add(p.Timeout)
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	// cancelTask is the best-effort abort for an abandoned wait; ctx is
+	// already dead at that point, so it runs on its own short deadline.
+	cancelTask := func() {
+		cancelCtx, cancel := context.WithTimeout(context.Background(), parseCancelTimeout)
+		_, _ = c.ParseCancel(cancelCtx, taskID)
+		cancel()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			cancelTask()
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("prismer: parse task %s did not complete within %s", taskID, p.Timeout)
+		}
+
+		status, err := c.ParseStatus(ctx, taskID)
+		if err != nil {
+			if ctx.Err() != nil {
+				cancelTask()
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		switch status.Status {
+		case "completed":
+			return c.ParseResultByID(ctx, taskID)
+		case "failed":
+			return nil, &ParseTaskFailedError{TaskID: taskID, Err: status.Error}
+		case "cancelled":
+			return nil, fmt.Errorf("prismer: parse task %s was cancelled", taskID)
+		}
+	}
+}
+
+// ParseResultByID fetches the final document of a completed parse task.
+func (c *Client) ParseResultByID(ctx context.Context, taskID string) (*ParseResult, error) {
+	raw, err := c.doRequest(ctx, http.MethodGet, "/api/parse/"+taskID+"/result", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON[ParseResult](raw)
+}
@@ -0,0 +1,141 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseAndWaitPollsToCompletion(t *testing.T) {
+	var statusCalls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/parse":
+			_ = json.NewEncoder(w).Encode(ParseResult{Success: true, TaskID: "t1", Status: "processing"})
+		case "/api/parse/t1/status":
+			statusCalls++
+			status := "processing"
+			if statusCalls >= 2 {
+				status = "completed"
+			}
+			_ = json.NewEncoder(w).Encode(ParseResult{Success: true, TaskID: "t1", Status: status})
+		case "/api/parse/t1/result":
+			_ = json.NewEncoder(w).Encode(ParseResult{Success: true, Status: "completed", Markdown: "# doc"})
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	res, err := client.ParseAndWait(context.Background(), &ParseOptions{URL: "https://x/doc.pdf", Mode: "async"},
+		&PollOptions{Interval: time.Millisecond, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("ParseAndWait: %v", err)
+	}
+	if res.Markdown != "# doc" {
+		t.Fatalf("Markdown = %q", res.Markdown)
+	}
+	if statusCalls < 2 {
+		t.Fatalf("expected at least 2 status polls, got %d", statusCalls)
+	}
+}
+
+func TestParseAndWaitSurfacesFailedTask(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/parse":
+			_ = json.NewEncoder(w).Encode(ParseResult{Success: true, TaskID: "t2", Status: "processing"})
+		case "/api/parse/t2/status":
+			_ = json.NewEncoder(w).Encode(ParseResult{Success: false, TaskID: "t2", Status: "failed",
+				Error: &APIError{Code: "PARSE_ERROR", Message: "corrupt file"}})
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	_, err := client.ParseAndWait(context.Background(), &ParseOptions{URL: "https://x/bad.pdf", Mode: "async"},
+		&PollOptions{Interval: time.Millisecond, Timeout: time.Second})
+
+	failed, ok := err.(*ParseTaskFailedError)
+	if !ok {
+		t.Fatalf("expected *ParseTaskFailedError, got %v", err)
+	}
+	if failed.TaskID != "t2" || failed.Err == nil || failed.Err.Code != "PARSE_ERROR" {
+		t.Fatalf("unexpected failure detail: %+v", failed)
+	}
+}
+
+func TestWaitForParseTaskCancelsAbandonedTask(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/parse/t1/status":
+			_ = json.NewEncoder(w).Encode(ParseResult{Success: true, TaskID: "t1", Status: "processing"})
+		case "/api/parse/t1/cancel":
+			if r.Method != http.MethodPost {
+				t.Errorf("cancel used %s, want POST", r.Method)
+			}
+			cancelled <- struct{}{}
+			_ = json.NewEncoder(w).Encode(ParseResult{Success: true, TaskID: "t1", Status: "cancelled"})
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	_, err := client.WaitForParseTask(ctx, "t1", &PollOptions{Interval: time.Millisecond, Timeout: time.Minute})
+	if err != context.Canceled {
+		t.Fatalf("WaitForParseTask error = %v, want context.Canceled", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("abandoning the wait did not cancel the task server-side")
+	}
+}
+
+func TestParseStreamEmitsIncrementalEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/parse/task1/stream" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(
+			"event: page\ndata: {\"page\":1,\"markdown\":\"# One\"}\n\n" +
+				"event: page\ndata: {\"page\":2,\"markdown\":\"# Two\"}\n\n" +
+				"event: complete\ndata: {\"status\":\"completed\"}\n\n"))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	events, err := client.ParseStream(context.Background(), "task1")
+	if err != nil {
+		t.Fatalf("ParseStream: %v", err)
+	}
+
+	var got []ParseStreamEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != 3 {
+		t.Fatalf("received %d events, want 3", len(got))
+	}
+	if got[0].Type != "page" || got[0].Page != 1 || got[0].Markdown != "# One" {
+		t.Fatalf("first event = %+v", got[0])
+	}
+	if got[2].Type != "complete" || got[2].Status != "completed" {
+		t.Fatalf("final event = %+v", got[2])
+	}
+}
@@ -0,0 +1,728 @@
+// Package prismer is the official Go SDK for the Prismer.AI platform.
+//
+// It wraps the Context API (save/load/search of crawled content), the Parse
+// API (document parsing) and the IM API (agent-to-agent messaging) exposed by
+// a Prismer deployment. A Client is safe for use from multiple goroutines.
+package prismer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Version is the SDK version, reported in the User-Agent header.
+const Version = "0.4.0"
+
+// Environment names a well-known Prismer deployment.
+type Environment string
+
+// Well-known deployments selectable with WithEnvironment.
+const (
+	// EnvironmentProduction is the hosted Prismer cloud.
+	EnvironmentProduction Environment = "production"
+
+	// EnvironmentStaging is the pre-release deployment.
+	EnvironmentStaging Environment = "staging"
+
+	// EnvironmentDevelopment is a local development stack.
+	EnvironmentDevelopment Environment = "development"
+)
+
+var (
+	envMu        sync.RWMutex
+	environments = map[Environment]string{
+		EnvironmentProduction:  "https://prismer.cloud",
+		EnvironmentStaging:     "https://staging.prismer.cloud",
+		EnvironmentDevelopment: "http://localhost:3000",
+	}
+)
+
+// RegisterEnvironment adds (or overrides) a named environment, so custom
+// deployments can be selected by name, e.g. from a CLI config value.
+func RegisterEnvironment(env Environment, baseURL string) {
+	envMu.Lock()
+	defer envMu.Unlock()
+	environments[env] = baseURL
+}
+
+// mustEnvironmentBaseURL resolves a built-in environment.
+func mustEnvironmentBaseURL(env Environment) string {
+	base, _ := environmentBaseURL(env)
+	return base
+}
+
+// environmentBaseURL resolves a named environment.
+func environmentBaseURL(env Environment) (string, bool) {
+	envMu.RLock()
+	defer envMu.RUnlock()
+	base, ok := environments[env]
+	return base, ok
+}
+
+const defaultTimeout = 30 * time.Second
+
+// Client is the entry point for all Prismer APIs.
+type Client struct {
+	baseURL    string
+	initErr    error
+	userAgent  string
+	httpClient *http.Client
+
+	// apiKey is guarded by tokenMu: SetToken may rotate it from a
+	// background goroutine while requests are being built.
+	tokenMu sync.RWMutex
+	apiKey  string
+
+	middlewares  []RequestMiddleware
+	encryptor    MessageEncryptor
+	decryptor    MessageDecryptor
+	panicHandler PanicHandler
+
+	retryMaxAttempts int
+	retryBaseDelay   time.Duration
+
+	autoRefresh bool
+	refreshErr  func(error)
+
+	requestHook  func(context.Context, *http.Request)
+	responseHook func(RequestInfo)
+
+	closersMu sync.Mutex
+	closers   []io.Closer
+
+	im *IMClient
+}
+
+// registerCloser records a long-lived component (offline manager, realtime
+// connection, token refresher, ...) owned by this client so Close can fan
+// out to it.
+func (c *Client) registerCloser(cl io.Closer) {
+	c.closersMu.Lock()
+	defer c.closersMu.Unlock()
+	c.closers = append(c.closers, cl)
+}
+
+// Close shuts down all background work owned by the client — realtime
+// connections, offline managers, the token refresher — and releases idle
+// HTTP connections. Call it via defer next to NewClient; it is safe to
+// call more than once.
+func (c *Client) Close() error {
+	c.closersMu.Lock()
+	closers := c.closers
+	c.closers = nil
+	c.closersMu.Unlock()
+
+	var errs []error
+	for _, cl := range closers {
+		if err := cl.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.httpClient.CloseIdleConnections()
+	return errors.Join(errs...)
+}
+
+// RequestMiddleware inspects or mutates an outbound request before it is
+// sent, e.g. for custom auth, request signing or tracing. Returning an
+// error aborts the request.
+type RequestMiddleware func(*http.Request) error
+
+// ClientOption configures a Client at construction time.
+type ClientOption func(*Client)
+
+// WithBaseURL points the client at a specific deployment, overriding the
+// environment default.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) { c.baseURL = baseURL }
+}
+
+// WithEnvironment selects a well-known or registered deployment by name.
+// An unknown name records an error that every subsequent request returns,
+// rather than silently keeping the production default.
+func WithEnvironment(env Environment) ClientOption {
+	return func(c *Client) {
+		base, ok := environmentBaseURL(env)
+		if !ok {
+			c.initErr = fmt.Errorf("prismer: unknown environment %q", env)
+			return
+		}
+		c.baseURL = base
+	}
+}
+
+// RequestInfo describes one completed HTTP attempt, successful or not,
+// as reported to the WithResponseHook callback.
+type RequestInfo struct {
+	Method     string
+	Path       string
+	StatusCode int
+
+	// RequestID is the X-Request-Id response header, when present.
+	RequestID string
+
+	// Duration is the round-trip time of this attempt.
+	Duration time.Duration
+
+	// Attempt counts retries: 0 for the first try of a request.
+	Attempt int
+
+	// Err is the transport error, nil when a response was received.
+	Err error
+}
+
+// WithRequestHook registers a callback invoked with the request context
+// just before each HTTP request is sent, after auth and middleware have
+// run. It exists for context propagation — e.g. calling OpenTelemetry
+// propagators to inject traceparent/baggage headers — without the SDK
+// depending on a tracing library.
+func WithRequestHook(hook func(ctx context.Context, req *http.Request)) ClientOption {
+	return func(c *Client) { c.requestHook = hook }
+}
+
+// WithResponseHook registers a callback invoked after every HTTP attempt
+// (including failed ones and each retry), for wiring the SDK into
+// metrics, logging or tracing. The hook runs synchronously on the request
+// path and must not block.
+func WithResponseHook(hook func(RequestInfo)) ClientOption {
+	return func(c *Client) { c.responseHook = hook }
+}
+
+// WithHTTPClient replaces the underlying *http.Client, e.g. to configure
+// proxies or TLS settings.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// TransportOptions tunes the HTTP connection pool. The zero value of a
+// field keeps the net/http default. Agents that fan out to many
+// conversations should raise MaxIdleConnsPerHost (the default of 2
+// throttles concurrent reuse against a single deployment); 32 is a sensible
+// starting point for high-throughput workloads.
+type TransportOptions struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+// WithTransportTuning applies connection-pool settings to the client's
+// transport. It replaces the transport of the HTTP client in use, so
+// combine it with WithHTTPClient by ordering WithHTTPClient first.
+func WithTransportTuning(opts TransportOptions) ClientOption {
+	return func(c *Client) {
+		base, ok := c.httpClient.Transport.(*http.Transport)
+		if !ok {
+			if def, isDef := http.DefaultTransport.(*http.Transport); isDef {
+				base = def
+			} else {
+				return
+			}
+		}
+		t := base.Clone()
+		if opts.MaxIdleConns > 0 {
+			t.MaxIdleConns = opts.MaxIdleConns
+		}
+		if opts.MaxIdleConnsPerHost > 0 {
+			t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+		}
+		if opts.IdleConnTimeout > 0 {
+			t.IdleConnTimeout = opts.IdleConnTimeout
+		}
+		c.httpClient.Transport = t
+	}
+}
+
+// WithRequestMiddleware appends a middleware to the outbound request chain.
+// Middlewares run in registration order after the SDK has set its own
+// headers, so they may override them.
+func WithRequestMiddleware(mw RequestMiddleware) ClientOption {
+	return func(c *Client) { c.middlewares = append(c.middlewares, mw) }
+}
+
+// WithRetry enables automatic retries with exponential backoff for
+// idempotent requests: GETs, and writes carrying an idempotency key (the
+// Idempotency-Key header set by the offline outbox, or a legacy
+// _idempotencyKey payload field).
+// Retries fire on 429/502/503/504 responses and network errors, honor the
+// Retry-After header, and stop when ctx is cancelled. maxAttempts counts
+// the initial attempt.
+func WithRetry(maxAttempts int, baseDelay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retryMaxAttempts = maxAttempts
+		c.retryBaseDelay = baseDelay
+	}
+}
+
+// PanicHandler observes a panic recovered from a user-supplied event
+// handler, together with the stack at the point of the panic.
+type PanicHandler func(recovered any, stack []byte)
+
+// defaultPanicHandler logs the panic and continues, so one misbehaving
+// handler can neither crash the program nor fail silently.
+func defaultPanicHandler(recovered any, stack []byte) {
+	log.Printf("prismer: event handler panicked: %v\n%s", recovered, stack)
+}
+
+// WithPanicHandler sets the policy for panics in offline and realtime
+// event handlers. The default logs and continues; pass a handler that
+// calls panic(recovered) to make handler bugs fatal while debugging.
+func WithPanicHandler(h PanicHandler) ClientOption {
+	return func(c *Client) { c.panicHandler = h }
+}
+
+// WithUserAgent overrides the User-Agent header sent with every request.
+func WithUserAgent(ua string) ClientOption {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// NewClient creates a Client authenticated with apiKey (or an IM token).
+func NewClient(apiKey string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:      mustEnvironmentBaseURL(EnvironmentProduction),
+		apiKey:       apiKey,
+		userAgent:    "prismer-go/" + Version,
+		httpClient:   &http.Client{Timeout: defaultTimeout},
+		panicHandler: defaultPanicHandler,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.im = newIMClient(c)
+	if c.autoRefresh {
+		r := newTokenRefresher(c)
+		c.registerCloser(r)
+		go r.run()
+	}
+	return c
+}
+
+// IM returns the messaging API client.
+func (c *Client) IM() *IMClient { return c.im }
+
+// SetToken replaces the credential used for subsequent requests. It is
+// used after Account.RefreshToken rotates an IM token and is safe to call
+// concurrently with in-flight requests.
+func (c *Client) SetToken(token string) {
+	c.tokenMu.Lock()
+	c.apiKey = token
+	c.tokenMu.Unlock()
+}
+
+// token reads the current credential.
+func (c *Client) token() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.apiKey
+}
+
+// BaseURL reports the deployment the client talks to.
+func (c *Client) BaseURL() string { return c.baseURL }
+
+// Do issues an arbitrary authenticated request against the deployment and
+// returns the raw response body. It is the low-level escape hatch for
+// endpoints the SDK has no typed wrapper for yet; body, when non-nil, is
+// JSON-encoded.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body any) ([]byte, error) {
+	return c.doRequest(ctx, method, path, query, body)
+}
+
+// requestDoer is the request primitive higher layers are built on. *Client
+// is the production implementation; tests substitute fakes to simulate
+// failures, timeouts and canned responses without a live server.
+type requestDoer interface {
+	doRequest(ctx context.Context, method, path string, query url.Values, body any) ([]byte, error)
+}
+
+// headerRequestDoer is implemented by doers that can also surface response
+// headers, which some deployments use for pagination cursors. imCall
+// upgrades to it when available.
+type headerRequestDoer interface {
+	doRequestHeaders(ctx context.Context, method, path string, query url.Values, body any) ([]byte, http.Header, error)
+}
+
+// retryableStatuses are the response codes WithRetry considers transient.
+var retryableStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// RetryError wraps the final failure after WithRetry exhausted its
+// attempts, reporting how many were made.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("prismer: request failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// hasIdempotencyKey reports whether a write payload is safe to retry
+// because it carries a deduplication key, either top-level or in the
+// message metadata (where older offline layers put it).
+func hasIdempotencyKey(body any) bool {
+	payload, ok := body.(map[string]any)
+	if !ok {
+		return false
+	}
+	if _, ok := payload["_idempotencyKey"]; ok {
+		return true
+	}
+	meta, _ := payload["metadata"].(map[string]any)
+	_, ok = meta["_idempotencyKey"]
+	return ok
+}
+
+// idempotencyKeyCtxKey carries an Idempotency-Key value through a request
+// context; doOnce turns it into the header of the same name. The offline
+// outbox sets it on every dispatched write so redelivery of any op type is
+// deduplicated uniformly, instead of only sends and edits via metadata.
+type idempotencyKeyCtxKey struct{}
+
+// withIdempotencyKey returns ctx tagged with an Idempotency-Key for the
+// requests issued under it.
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+// idempotencyKeyFromContext extracts the key set by withIdempotencyKey,
+// empty when absent.
+func idempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtxKey{}).(string)
+	return key
+}
+
+// doRequest performs an authenticated HTTP request against the configured
+// deployment and returns the raw response body. With WithRetry configured,
+// idempotent requests are retried on transient failures.
+func (c *Client) doRequest(ctx context.Context, method, path string, query url.Values, body any) ([]byte, error) {
+	data, _, err := c.doRequestHeaders(ctx, method, path, query, body)
+	return data, err
+}
+
+// doRequestHeaders is doRequest with the final response's headers, for
+// callers that read pagination cursors out of them.
+func (c *Client) doRequestHeaders(ctx context.Context, method, path string, query url.Values, body any) ([]byte, http.Header, error) {
+	if c.initErr != nil {
+		return nil, nil, c.initErr
+	}
+	attempts := 1
+	if c.retryMaxAttempts > 1 && (method == http.MethodGet || idempotencyKeyFromContext(ctx) != "" || hasIdempotencyKey(body)) {
+		attempts = c.retryMaxAttempts
+	}
+
+	var (
+		lastErr    error
+		retryAfter time.Duration
+	)
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := c.retryBaseDelay << (attempt - 1)
+			if retryAfter > 0 {
+				delay = retryAfter
+			}
+			select {
+			case <-ctx.Done():
+				return nil, nil, &RetryError{Attempts: attempt, Err: ctx.Err()}
+			case <-time.After(delay):
+			}
+		}
+
+		started := time.Now()
+		data, header, status, ra, err := c.doOnce(ctx, method, path, query, body)
+		if c.responseHook != nil {
+			info := RequestInfo{
+				Method:     method,
+				Path:       path,
+				StatusCode: status,
+				Duration:   time.Since(started),
+				Attempt:    attempt,
+				Err:        err,
+			}
+			if header != nil {
+				info.RequestID = header.Get("X-Request-Id")
+			}
+			c.responseHook(info)
+		}
+		if err == nil && !retryableStatuses[status] {
+			return data, header, nil
+		}
+		if err == nil {
+			// Transient status with a decodable envelope: retry, but hand
+			// the body through unchanged if this was the last attempt.
+			if attempt == attempts-1 {
+				return data, header, nil
+			}
+			lastErr = fmt.Errorf("prismer: server returned status %d", status)
+		} else {
+			lastErr = err
+		}
+		retryAfter = ra
+	}
+	if attempts == 1 {
+		return nil, nil, lastErr
+	}
+	return nil, nil, &RetryError{Attempts: attempts, Err: lastErr}
+}
+
+// doOnce performs a single HTTP attempt, reporting the response headers,
+// status code and any Retry-After hint alongside the body.
+func (c *Client) doOnce(ctx context.Context, method, path string, query url.Values, body any) ([]byte, http.Header, int, time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, 0, 0, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, nil, 0, 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token())
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key := idempotencyKeyFromContext(ctx); key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	if err := c.applyMiddleware(req); err != nil {
+		return nil, nil, 0, 0, err
+	}
+	if c.requestHook != nil {
+		c.requestHook(ctx, req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, 0, 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, resp.StatusCode, retryAfter, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, resp.Header, resp.StatusCode, retryAfter, rateLimitErrorFromResponse(resp, retryAfter)
+	}
+	// Error statuses with a JSON body carry the structured API envelope and
+	// are surfaced through it; anything else (an HTML error page, a bare
+	// proxy response) becomes a typed HTTPError so the status isn't masked
+	// as a decode failure downstream.
+	if resp.StatusCode >= 400 && !json.Valid(data) {
+		return nil, resp.Header, resp.StatusCode, retryAfter, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+	}
+	return data, resp.Header, resp.StatusCode, retryAfter, nil
+}
+
+// RateLimitError reports a 429 response together with the server's
+// rate-limit headers, so callers can pace themselves instead of guessing.
+type RateLimitError struct {
+	// RetryAfter is the server-requested wait, zero if not sent.
+	RetryAfter time.Duration
+	// Remaining is X-RateLimit-Remaining, -1 if not sent.
+	Remaining int
+	// Reset is when the quota window resets, zero if not sent.
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("prismer: rate limited, retry after %s", e.RetryAfter)
+	}
+	return "prismer: rate limited"
+}
+
+// rateLimitErrorFromResponse builds a RateLimitError from a 429 response.
+func rateLimitErrorFromResponse(resp *http.Response, retryAfter time.Duration) *RateLimitError {
+	e := &RateLimitError{RetryAfter: retryAfter, Remaining: -1}
+	if v := resp.Header.Get("X-RateLimit-Remaining"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			e.Remaining = n
+		}
+	}
+	if v := resp.Header.Get("X-RateLimit-Reset"); v != "" {
+		if unix, err := strconv.ParseInt(v, 10, 64); err == nil {
+			e.Reset = time.Unix(unix, 0)
+		}
+	}
+	return e
+}
+
+// parseRetryAfter interprets a Retry-After header in either the seconds or
+// HTTP-date form, returning zero when absent or unparseable.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// HTTPError reports a non-2xx response whose body is not the structured
+// API envelope. Use errors.As to branch on StatusCode.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	body := string(e.Body)
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	return fmt.Sprintf("prismer: HTTP %s: %s", e.Status, body)
+}
+
+// applyMiddleware runs the registered request middlewares in order. It is
+// shared by doRequest and the realtime and upload request builders.
+func (c *Client) applyMiddleware(req *http.Request) error {
+	for _, mw := range c.middlewares {
+		if err := mw(req); err != nil {
+			return fmt.Errorf("request middleware: %w", err)
+		}
+	}
+	return nil
+}
+
+// decodeJSON unmarshals an API response body into T.
+func decodeJSON[T any](data []byte) (*T, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &v, nil
+}
+
+// APIError is a structured error returned by the Prismer API.
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	// Details is the raw `details` object some endpoints attach, e.g.
+	// field-level reasons on a validation failure. Nil when the error
+	// arrived as a plain string or carried no details. FieldErrors
+	// decodes the common per-field form.
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+// UnmarshalJSON accepts both the structured {code, message} object and the
+// plain string form some endpoints return.
+func (e *APIError) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		e.Code = "UNKNOWN"
+		e.Message = s
+		return nil
+	}
+	type alias APIError
+	var a alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*e = APIError(a)
+	return nil
+}
+
+// FieldErrors decodes Details as the per-field map validation errors
+// use ({"field": "reason", ...}). It returns nil when there are no
+// details or they take a different shape, so callers can fall back to
+// the generic Message.
+func (e *APIError) FieldErrors() map[string]string {
+	if len(e.Details) == 0 {
+		return nil
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(e.Details, &fields); err != nil || len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("prismer: %s: %s", e.Code, e.Message)
+	}
+	return "prismer: " + e.Message
+}
+
+// IMResult is the envelope every IM endpoint responds with.
+type IMResult struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   *APIError       `json:"error,omitempty"`
+
+	// NextCursor is the cursor for the next page of a list result. The
+	// hosted deployment returns it in the body; self-hosted proxies may
+	// use the X-Next-Cursor or Link response headers instead, and imCall
+	// folds either form into this field.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// OK reports whether the call succeeded server-side.
+func (r *IMResult) OK() bool { return r.Success }
+
+// Err returns the server error, or nil on success.
+func (r *IMResult) Err() error {
+	if r.Success || r.Error == nil {
+		return nil
+	}
+	return r.Error
+}
+
+// Decode unmarshals the result payload into v.
+func (r *IMResult) Decode(v any) error {
+	if len(r.Data) == 0 {
+		return ErrNoData
+	}
+	return json.Unmarshal(r.Data, v)
+}
+
+// generateID returns a random identifier with the given prefix, used for
+// client-side message IDs and outbox operations.
+func generateID(prefix string) string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
+	}
+	return prefix + "-" + hex.EncodeToString(buf)
+}
+
+// nowRFC3339 formats the current UTC time the way the server does.
+func nowRFC3339() string {
+	return formatRFC3339(time.Now())
+}
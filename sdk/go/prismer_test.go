@@ -0,0 +1,265 @@
+package prismer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDoRequestSurfacesNonJSONErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html>502 Bad Gateway</html>"))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	_, err := client.Do(context.Background(), http.MethodGet, "/api/im/me", nil, nil)
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("expected *HTTPError, got %v", err)
+	}
+	if httpErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("StatusCode = %d, want 502", httpErr.StatusCode)
+	}
+}
+
+func TestWithRetryRetriesTransientStatusOnGet(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"success":false,"error":{"code":"UNAVAILABLE","message":"try later"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL), WithRetry(3, time.Millisecond))
+	raw, err := client.Do(context.Background(), http.MethodGet, "/api/im/me", nil, nil)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+	res, err := decodeJSON[IMResult](raw)
+	if err != nil || !res.OK() {
+		t.Fatalf("unexpected final response: %v %v", res, err)
+	}
+}
+
+func TestWithRetryDoesNotRetryPlainPost(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"success":false}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL), WithRetry(3, time.Millisecond))
+	if _, err := client.Do(context.Background(), http.MethodPost, "/api/im/groups", nil, map[string]any{"name": "g"}); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("non-idempotent POST retried: %d attempts", calls)
+	}
+}
+
+func TestWithRetryReportsAttemptsOnExhaustion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("bad gateway"))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL), WithRetry(2, time.Millisecond))
+	_, err := client.Do(context.Background(), http.MethodGet, "/api/im/me", nil, nil)
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected *RetryError, got %v", err)
+	}
+	if retryErr.Attempts != 2 {
+		t.Fatalf("Attempts = %d, want 2", retryErr.Attempts)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("underlying HTTPError not preserved: %v", err)
+	}
+}
+
+func TestRateLimitErrorCarriesHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	_, err := client.Do(context.Background(), http.MethodPost, "/api/im/direct/u1/messages", nil, map[string]any{"content": "x"})
+
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("expected *RateLimitError, got %v", err)
+	}
+	if rlErr.RetryAfter != 7*time.Second {
+		t.Fatalf("RetryAfter = %s, want 7s", rlErr.RetryAfter)
+	}
+	if rlErr.Remaining != 0 {
+		t.Fatalf("Remaining = %d, want 0", rlErr.Remaining)
+	}
+	if rlErr.Reset.Unix() != 1700000000 {
+		t.Fatalf("Reset = %v", rlErr.Reset)
+	}
+}
+
+func TestDoRequestKeepsJSONErrorEnvelope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"success":false,"error":{"code":"UNAUTHORIZED","message":"bad token"}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	raw, err := client.Do(context.Background(), http.MethodGet, "/api/im/me", nil, nil)
+	if err != nil {
+		t.Fatalf("expected envelope body, got error %v", err)
+	}
+	res, err := decodeJSON[IMResult](raw)
+	if err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	if res.Err() == nil || res.Error.Code != "UNAUTHORIZED" {
+		t.Fatalf("unexpected envelope: %+v", res)
+	}
+}
+
+func TestWithEnvironmentUnknownNameFailsRequests(t *testing.T) {
+	client := NewClient("k", WithEnvironment("nonsense"))
+	if _, err := client.doRequest(context.Background(), http.MethodGet, "/api/im/me", nil, nil); err == nil {
+		t.Fatal("expected error for unknown environment")
+	}
+}
+
+func TestRegisterEnvironmentMakesNameSelectable(t *testing.T) {
+	RegisterEnvironment("onprem", "https://prismer.example.com")
+	client := NewClient("k", WithEnvironment("onprem"))
+	if got := client.BaseURL(); got != "https://prismer.example.com" {
+		t.Fatalf("BaseURL = %q, want registered base", got)
+	}
+}
+
+func TestSetTokenSafeDuringRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"data":{}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("initial", WithBaseURL(srv.URL))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			client.SetToken("rotated")
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if _, err := client.doRequest(context.Background(), http.MethodGet, "/api/im/me", nil, nil); err != nil {
+			t.Errorf("doRequest: %v", err)
+			break
+		}
+	}
+	<-done
+}
+
+func TestClientCloseFansOutToOwnedComponents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"data":{"events":[],"hasMore":false}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	m := NewOfflineManager(client, nil)
+	if err := m.Init(context.Background()); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// The offline manager was closed through the client, so closing it
+	// again must be a no-op rather than a double shutdown.
+	if err := m.Close(); err != nil {
+		t.Fatalf("offline manager Close after client Close: %v", err)
+	}
+	// Closing the client twice is allowed.
+	if err := client.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func TestResponseHookSeesEveryAttempt(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Request-Id", "req-42")
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"data":{}}`))
+	}))
+	defer srv.Close()
+
+	var infos []RequestInfo
+	client := NewClient("k",
+		WithBaseURL(srv.URL),
+		WithRetry(3, time.Millisecond),
+		WithResponseHook(func(info RequestInfo) { infos = append(infos, info) }))
+
+	if _, err := client.doRequest(context.Background(), http.MethodGet, "/api/im/me", nil, nil); err != nil {
+		t.Fatalf("doRequest: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("hook saw %d attempts, want 2", len(infos))
+	}
+	if infos[0].StatusCode != http.StatusServiceUnavailable || infos[0].Attempt != 0 {
+		t.Fatalf("first attempt info = %+v", infos[0])
+	}
+	if infos[1].StatusCode != http.StatusOK || infos[1].Attempt != 1 || infos[1].RequestID != "req-42" {
+		t.Fatalf("second attempt info = %+v", infos[1])
+	}
+	if infos[1].Duration <= 0 {
+		t.Fatalf("Duration = %v, want > 0", infos[1].Duration)
+	}
+}
+
+func TestRequestHookCanInjectTraceHeaders(t *testing.T) {
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`{"success":true,"data":{}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL),
+		WithRequestHook(func(ctx context.Context, req *http.Request) {
+			req.Header.Set("traceparent", "00-abc-def-01")
+		}))
+	if _, err := client.doRequest(context.Background(), http.MethodGet, "/api/im/me", nil, nil); err != nil {
+		t.Fatalf("doRequest: %v", err)
+	}
+	if traceparent != "00-abc-def-01" {
+		t.Fatalf("traceparent = %q, hook header not sent", traceparent)
+	}
+}
@@ -0,0 +1,1130 @@
+package prismer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// Realtime event types delivered by the WS and SSE transports.
+const (
+	RealtimeEventMessageNew      = "message.new"
+	RealtimeEventMessageRead     = "message.read"
+	RealtimeEventTypingIndicator = "typing.indicator"
+	RealtimeEventPresenceChanged = "presence.changed"
+)
+
+const (
+	defaultHeartbeatInterval    = 30 * time.Second
+	defaultReconnectBaseDelay   = time.Second
+	defaultMaxReconnectAttempts = 5
+	defaultPingTimeout          = 10 * time.Second
+	defaultMaxMissedHeartbeats  = 3
+	authTimeout                 = 10 * time.Second
+)
+
+// RealtimeConfig configures a realtime connection. The zero value connects
+// to the client's deployment with the client's token.
+type RealtimeConfig struct {
+	// URL overrides the derived realtime endpoint.
+	URL string
+
+	// Token overrides the client credential for the realtime handshake.
+	Token string
+
+	// HTTPClient is used for the SSE stream request and the WS dial, so
+	// proxies, TLS settings and custom transports apply to both.
+	HTTPClient *http.Client
+
+	// QueryParamAuth sends the token as a ?token= query parameter on the
+	// WS dial instead of an Authorization header. Query-param auth leaks
+	// the token into proxy and access logs, so it is off by default; turn
+	// it on only for servers that cannot read headers during the
+	// WebSocket upgrade.
+	QueryParamAuth bool
+
+	// AutoReconnect re-establishes dropped connections with exponential
+	// backoff, and also retries the initial connect.
+	AutoReconnect bool
+
+	// MaxReconnectAttempts bounds consecutive reconnect attempts.
+	// Defaults to 5.
+	MaxReconnectAttempts int
+
+	// ReconnectBaseDelay is the first backoff delay, doubled per attempt.
+	// Defaults to one second.
+	ReconnectBaseDelay time.Duration
+
+	// HeartbeatInterval is the WS ping cadence. Defaults to 30 seconds.
+	HeartbeatInterval time.Duration
+
+	// PingTimeout is how long a heartbeat ping waits for its pong before
+	// counting as missed. Defaults to 10 seconds.
+	PingTimeout time.Duration
+
+	// MaxMissedHeartbeats is how many consecutive missed pongs are
+	// tolerated before the connection is torn down. Defaults to 3, so a
+	// single slow pong on an otherwise alive connection is a warning, not
+	// a close.
+	MaxMissedHeartbeats int
+}
+
+// normalize fills config defaults from the owning client.
+func (cfg *RealtimeConfig) normalize(c *Client) {
+	if cfg.Token == "" {
+		cfg.Token = c.token()
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = c.httpClient
+	}
+	if cfg.MaxReconnectAttempts <= 0 {
+		cfg.MaxReconnectAttempts = defaultMaxReconnectAttempts
+	}
+	if cfg.ReconnectBaseDelay <= 0 {
+		cfg.ReconnectBaseDelay = defaultReconnectBaseDelay
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.PingTimeout <= 0 {
+		cfg.PingTimeout = defaultPingTimeout
+	}
+	if cfg.MaxMissedHeartbeats <= 0 {
+		cfg.MaxMissedHeartbeats = defaultMaxMissedHeartbeats
+	}
+}
+
+// eventEnvelope is the wire frame of every realtime event and command.
+type eventEnvelope struct {
+	Type      string          `json:"type"`
+	RequestID string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// MessageNewPayload is the payload of a message.new event.
+type MessageNewPayload struct {
+	ConversationID string    `json:"conversationId"`
+	Message        IMMessage `json:"message"`
+}
+
+// TypingPayload is the payload of a typing.indicator event.
+type TypingPayload struct {
+	ConversationID string `json:"conversationId"`
+	UserID         string `json:"userId"`
+	IsTyping       bool   `json:"isTyping"`
+}
+
+// PresencePayload is the payload of a presence.changed event.
+type PresencePayload struct {
+	UserID string `json:"userId"`
+	Status string `json:"status"`
+}
+
+// eventDispatcher routes decoded envelopes to typed and generic handlers.
+// Handler panics are routed to the configured PanicHandler instead of
+// crashing the program.
+type eventDispatcher struct {
+	decrypt MessageDecryptor
+	onPanic PanicHandler
+
+	mu            sync.RWMutex
+	onMessageNew  []func(*MessageNewPayload)
+	onMessageRead []func(*ReadReceipt)
+	onTyping      []func(*TypingPayload)
+	onPresence    []func(*PresencePayload)
+	generic       map[string][]func(json.RawMessage)
+	wildcard      []func(string, json.RawMessage)
+	msgSubs       []*messageSubscription
+}
+
+// defaultSubscribeBuffer is the channel capacity of SubscribeMessages when
+// the caller passes no explicit bound.
+const defaultSubscribeBuffer = 64
+
+// messageSubscription is one ordered message.new consumer: a bounded
+// channel fed from the read loop, so delivery order matches receive order
+// — unlike the handler callbacks, which each run on their own goroutine.
+type messageSubscription struct {
+	ch     chan *MessageNewPayload
+	onDrop func(*MessageNewPayload)
+}
+
+// subscribeMessages registers an ordered consumer and returns its channel
+// plus a cancel func that unregisters it and closes the channel.
+func (d *eventDispatcher) subscribeMessages(buffer int, onDrop func(*MessageNewPayload)) (<-chan *MessageNewPayload, func()) {
+	if buffer <= 0 {
+		buffer = defaultSubscribeBuffer
+	}
+	sub := &messageSubscription{ch: make(chan *MessageNewPayload, buffer), onDrop: onDrop}
+	d.mu.Lock()
+	d.msgSubs = append(d.msgSubs, sub)
+	d.mu.Unlock()
+	cancel := func() {
+		d.mu.Lock()
+		for i, s := range d.msgSubs {
+			if s == sub {
+				d.msgSubs = append(d.msgSubs[:i], d.msgSubs[i+1:]...)
+				break
+			}
+		}
+		d.mu.Unlock()
+		// No dispatch can be mid-send on sub.ch here: sends happen under
+		// the read lock we just excluded, so closing is safe.
+		close(sub.ch)
+	}
+	return sub.ch, cancel
+}
+
+// feedSubscriptions offers a message to every ordered consumer without
+// blocking the read loop: a full channel drops the event and reports it
+// through the subscription's drop callback.
+func (d *eventDispatcher) feedSubscriptions(p *MessageNewPayload) {
+	for _, sub := range d.msgSubs {
+		select {
+		case sub.ch <- p:
+		default:
+			if sub.onDrop != nil {
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							d.onPanic(r, debug.Stack())
+						}
+					}()
+					sub.onDrop(p)
+				}()
+			}
+		}
+	}
+}
+
+func newEventDispatcher(decrypt MessageDecryptor, onPanic PanicHandler) *eventDispatcher {
+	if onPanic == nil {
+		onPanic = defaultPanicHandler
+	}
+	return &eventDispatcher{decrypt: decrypt, onPanic: onPanic, generic: make(map[string][]func(json.RawMessage))}
+}
+
+// run invokes one handler on its own goroutine, recovering panics.
+func (d *eventDispatcher) run(h func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				d.onPanic(r, debug.Stack())
+			}
+		}()
+		h()
+	}()
+}
+
+func (d *eventDispatcher) dispatch(env *eventEnvelope) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	switch env.Type {
+	case RealtimeEventMessageNew:
+		var p MessageNewPayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil {
+			decryptIMMessage(d.decrypt, &p.Message)
+			for _, h := range d.onMessageNew {
+				h := h
+				d.run(func() { h(&p) })
+			}
+			d.feedSubscriptions(&p)
+		}
+	case RealtimeEventMessageRead:
+		var p ReadReceipt
+		if err := json.Unmarshal(env.Payload, &p); err == nil {
+			for _, h := range d.onMessageRead {
+				h := h
+				d.run(func() { h(&p) })
+			}
+		}
+	case RealtimeEventTypingIndicator:
+		var p TypingPayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil {
+			for _, h := range d.onTyping {
+				h := h
+				d.run(func() { h(&p) })
+			}
+		}
+	case RealtimeEventPresenceChanged:
+		var p PresencePayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil {
+			for _, h := range d.onPresence {
+				h := h
+				d.run(func() { h(&p) })
+			}
+		}
+	}
+	for _, h := range d.generic[env.Type] {
+		h := h
+		d.run(func() { h(env.Payload) })
+	}
+	for _, h := range d.wildcard {
+		h := h
+		d.run(func() { h(env.Type, env.Payload) })
+	}
+}
+
+// RealtimeClient hands out realtime connections for the IM platform.
+type RealtimeClient struct{ c *Client }
+
+// ConnectWS establishes a WebSocket connection. cfg may be nil.
+func (r *RealtimeClient) ConnectWS(ctx context.Context, cfg *RealtimeConfig) (*RealtimeWSClient, error) {
+	conf := RealtimeConfig{}
+	if cfg != nil {
+		conf = *cfg
+	}
+	conf.normalize(r.c)
+	if conf.URL == "" {
+		conf.URL = wsURLFromBase(r.c.baseURL) + "/api/im/ws"
+	}
+	ws := &RealtimeWSClient{
+		cfg:          conf,
+		dispatcher:   newEventDispatcher(r.c.decryptor, r.c.panicHandler),
+		clk:          realClock{},
+		pendingPings: make(map[string]chan struct{}),
+	}
+	ws.rootCtx, ws.rootCancel = context.WithCancel(context.Background())
+	r.c.registerCloser(ws)
+	if err := ws.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// ConnectSSE establishes a server-sent-events stream. cfg may be nil.
+func (r *RealtimeClient) ConnectSSE(ctx context.Context, cfg *RealtimeConfig) (*RealtimeSSEClient, error) {
+	conf := RealtimeConfig{}
+	if cfg != nil {
+		conf = *cfg
+	}
+	conf.normalize(r.c)
+	if conf.URL == "" {
+		conf.URL = r.c.baseURL + "/api/im/events"
+	}
+	sse := &RealtimeSSEClient{c: r.c, cfg: conf, dispatcher: newEventDispatcher(r.c.decryptor, r.c.panicHandler), clk: realClock{}}
+	sse.rootCtx, sse.rootCancel = context.WithCancel(context.Background())
+	r.c.registerCloser(sse)
+	if err := sse.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return sse, nil
+}
+
+// wsURLFromBase converts an http(s) base URL into its ws(s) counterpart.
+func wsURLFromBase(base string) string {
+	switch {
+	case strings.HasPrefix(base, "https://"):
+		return "wss://" + strings.TrimPrefix(base, "https://")
+	case strings.HasPrefix(base, "http://"):
+		return "ws://" + strings.TrimPrefix(base, "http://")
+	default:
+		return base
+	}
+}
+
+// connectAttempts returns how often an initial connect may be tried: once,
+// or with backoff up to MaxReconnectAttempts when AutoReconnect is set.
+func connectAttempts(cfg *RealtimeConfig) int {
+	if cfg.AutoReconnect {
+		return cfg.MaxReconnectAttempts
+	}
+	return 1
+}
+
+// backoffDelay computes the exponential delay before attempt n (0-based).
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	return base << attempt
+}
+
+// RealtimeWSClient is a bidirectional realtime connection. Register
+// handlers before Connect returns events.
+type RealtimeWSClient struct {
+	cfg        RealtimeConfig
+	dispatcher *eventDispatcher
+	clk        clock
+
+	// rootCtx bounds the connection's background work; Disconnect cancels
+	// it, interrupting any reconnect waiting out its backoff.
+	rootCtx    context.Context
+	rootCancel context.CancelFunc
+
+	mu        sync.Mutex
+	conn      *websocket.Conn
+	connected bool
+	closed    bool
+	// connCancel tears down the current connection's heartbeat loop, so a
+	// reconnect never leaves the previous loop ticking alongside the new
+	// one.
+	connCancel context.CancelFunc
+	// abandoned marks a connection torn down by a failed connectOnce
+	// handshake: the caller's retry loop owns the redial, so the dying
+	// readLoop must not schedule a reconnect of its own.
+	abandoned         bool
+	reqSeq            int64
+	joined            map[string]struct{}
+	onHeartbeatMissed []func(int)
+
+	// writeMu serializes socket writes so concurrent Send calls cannot
+	// interleave frames; commands go out in the order writers acquire it.
+	writeMu sync.Mutex
+
+	pingMu       sync.Mutex
+	pendingPings map[string]chan struct{}
+
+	ackMu       sync.Mutex
+	pendingAcks map[string]chan *MessageNewPayload
+
+	authCh chan struct{}
+}
+
+// Connect dials the realtime endpoint and authenticates. With
+// AutoReconnect set, the initial connect is retried with exponential
+// backoff up to MaxReconnectAttempts, so a briefly unavailable gateway at
+// startup doesn't need a caller-side retry loop.
+func (c *RealtimeWSClient) Connect(ctx context.Context) error {
+	attempts := connectAttempts(&c.cfg)
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.clk.After(backoffDelay(c.cfg.ReconnectBaseDelay, attempt-1)):
+			}
+		}
+		if lastErr = c.connectOnce(ctx); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("websocket connect failed after %d attempts: %w", attempts, lastErr)
+}
+
+// connectOnce performs a single dial + authenticate cycle.
+func (c *RealtimeWSClient) connectOnce(ctx context.Context) error {
+	wsURL := c.cfg.URL
+	opts := &websocket.DialOptions{HTTPClient: c.cfg.HTTPClient}
+	if c.cfg.QueryParamAuth {
+		wsURL += "?token=" + url.QueryEscape(c.cfg.Token)
+	} else {
+		opts.HTTPHeader = http.Header{"Authorization": {"Bearer " + c.cfg.Token}}
+	}
+	conn, _, err := websocket.Dial(ctx, wsURL, opts)
+	if err != nil {
+		return err
+	}
+
+	connCtx, connCancel := context.WithCancel(c.rootCtx)
+	c.mu.Lock()
+	c.conn = conn
+	c.connected = true
+	c.closed = false
+	c.abandoned = false
+	c.connCancel = connCancel
+	c.authCh = make(chan struct{})
+	authCh := c.authCh
+	c.mu.Unlock()
+
+	go c.readLoop()
+
+	if err := c.Send(ctx, &eventEnvelope{Type: "auth", Payload: mustJSON(map[string]string{"token": c.cfg.Token})}); err != nil {
+		c.abandon()
+		return err
+	}
+
+	select {
+	case <-authCh:
+	case <-ctx.Done():
+		c.abandon()
+		return ctx.Err()
+	case <-c.clk.After(authTimeout):
+		c.abandon()
+		return fmt.Errorf("%w: timed out waiting for authenticated event", ErrAuthFailed)
+	}
+
+	// Restore subscriptions from before a drop: the server only routes
+	// conversation events to connections that joined them, so a reconnect
+	// without re-joining silently goes quiet.
+	c.mu.Lock()
+	rejoin := make([]string, 0, len(c.joined))
+	for id := range c.joined {
+		rejoin = append(rejoin, id)
+	}
+	c.mu.Unlock()
+	for _, id := range rejoin {
+		if err := c.sendJoin(ctx, id); err != nil {
+			c.abandon()
+			return fmt.Errorf("failed to rejoin conversation %s: %w", id, err)
+		}
+	}
+
+	go c.heartbeatLoop(connCtx)
+	return nil
+}
+
+// Send writes one command envelope to the socket. Writes are serialized,
+// so Send is safe to call from multiple goroutines.
+func (c *RealtimeWSClient) Send(ctx context.Context, env *eventEnvelope) error {
+	c.mu.Lock()
+	conn := c.conn
+	connected := c.connected
+	c.mu.Unlock()
+	if !connected || conn == nil {
+		return ErrNotConnected
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return conn.Write(ctx, websocket.MessageText, data)
+}
+
+// nextRequestID issues a per-connection command correlation ID.
+func (c *RealtimeWSClient) nextRequestID(prefix string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reqSeq++
+	return fmt.Sprintf("%s-%d", prefix, c.reqSeq)
+}
+
+// wsMessagePayload builds a message.send command body from the same
+// options the HTTP send path accepts, so threading (ParentID) and
+// metadata behave identically over both transports.
+func wsMessagePayload(conversationID, content string, opts *IMSendOptions) map[string]any {
+	payload := sendPayload(content, opts)
+	payload["conversationId"] = conversationID
+	return payload
+}
+
+// SendMessage sends a chat message over the socket.
+func (c *RealtimeWSClient) SendMessage(ctx context.Context, conversationID, content, msgType string) error {
+	var opts *IMSendOptions
+	if msgType != "" {
+		opts = &IMSendOptions{Type: MessageType(msgType)}
+	}
+	return c.SendMessageWithOptions(ctx, conversationID, content, opts)
+}
+
+// SendMessageWithOptions sends a chat message over the socket with the
+// full set of send options (type, ParentID, metadata, client ID).
+func (c *RealtimeWSClient) SendMessageWithOptions(ctx context.Context, conversationID, content string, opts *IMSendOptions) error {
+	if err := checkMessageType(opts); err != nil {
+		return err
+	}
+	return c.Send(ctx, &eventEnvelope{
+		Type:      "message.send",
+		RequestID: c.nextRequestID("msg"),
+		Payload:   mustJSON(wsMessagePayload(conversationID, content, opts)),
+	})
+}
+
+// SendMessageAck sends a chat message and blocks until the server echoes
+// an ack or message.new carrying the command's request ID, returning the
+// accepted message (with its server-assigned ID). Use it for reliable send
+// flows over the socket; SendMessage remains fire-and-forget.
+func (c *RealtimeWSClient) SendMessageAck(ctx context.Context, conversationID, content, msgType string) (*MessageNewPayload, error) {
+	var opts *IMSendOptions
+	if msgType != "" {
+		opts = &IMSendOptions{Type: MessageType(msgType)}
+	}
+	if err := checkMessageType(opts); err != nil {
+		return nil, err
+	}
+	id := c.nextRequestID("msg")
+	ch := make(chan *MessageNewPayload, 1)
+	c.ackMu.Lock()
+	if c.pendingAcks == nil {
+		c.pendingAcks = make(map[string]chan *MessageNewPayload)
+	}
+	c.pendingAcks[id] = ch
+	c.ackMu.Unlock()
+	defer func() {
+		c.ackMu.Lock()
+		delete(c.pendingAcks, id)
+		c.ackMu.Unlock()
+	}()
+
+	if err := c.Send(ctx, &eventEnvelope{Type: "message.send", RequestID: id, Payload: mustJSON(wsMessagePayload(conversationID, content, opts))}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-ch:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.clk.After(10 * time.Second):
+		return nil, errors.New("timed out waiting for message ack")
+	}
+}
+
+// resolveAck delivers a request-correlated message event to a waiting
+// SendMessageAck call, reporting whether one was waiting.
+func (c *RealtimeWSClient) resolveAck(env *eventEnvelope) bool {
+	c.ackMu.Lock()
+	ch, ok := c.pendingAcks[env.RequestID]
+	c.ackMu.Unlock()
+	if !ok {
+		return false
+	}
+	var p MessageNewPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return false
+	}
+	decryptIMMessage(c.dispatcher.decrypt, &p.Message)
+	select {
+	case ch <- &p:
+	default:
+	}
+	return true
+}
+
+// JoinConversation subscribes the connection to a conversation's events.
+// The subscription survives reconnects: joined conversations are tracked
+// and re-joined automatically after re-authentication.
+func (c *RealtimeWSClient) JoinConversation(ctx context.Context, conversationID string) error {
+	if err := c.sendJoin(ctx, conversationID); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if c.joined == nil {
+		c.joined = make(map[string]struct{})
+	}
+	c.joined[conversationID] = struct{}{}
+	c.mu.Unlock()
+	return nil
+}
+
+// LeaveConversation unsubscribes from a conversation and stops re-joining
+// it on reconnect.
+func (c *RealtimeWSClient) LeaveConversation(ctx context.Context, conversationID string) error {
+	c.mu.Lock()
+	delete(c.joined, conversationID)
+	c.mu.Unlock()
+	return c.Send(ctx, &eventEnvelope{
+		Type:    "conversation.leave",
+		Payload: mustJSON(map[string]string{"conversationId": conversationID}),
+	})
+}
+
+// sendJoin emits one conversation.join command without touching the
+// tracked set.
+func (c *RealtimeWSClient) sendJoin(ctx context.Context, conversationID string) error {
+	return c.Send(ctx, &eventEnvelope{
+		Type:    "conversation.join",
+		Payload: mustJSON(map[string]string{"conversationId": conversationID}),
+	})
+}
+
+// StartTyping signals that the user started typing in a conversation.
+func (c *RealtimeWSClient) StartTyping(ctx context.Context, conversationID string) error {
+	return c.Send(ctx, &eventEnvelope{
+		Type:    "typing.start",
+		Payload: mustJSON(map[string]string{"conversationId": conversationID}),
+	})
+}
+
+// StopTyping signals that the user stopped typing in a conversation.
+func (c *RealtimeWSClient) StopTyping(ctx context.Context, conversationID string) error {
+	return c.Send(ctx, &eventEnvelope{
+		Type:    "typing.stop",
+		Payload: mustJSON(map[string]string{"conversationId": conversationID}),
+	})
+}
+
+// UpdatePresence publishes the caller's presence status.
+func (c *RealtimeWSClient) UpdatePresence(ctx context.Context, status string) error {
+	return c.Send(ctx, &eventEnvelope{
+		Type:    "presence.update",
+		Payload: mustJSON(map[string]string{"status": status}),
+	})
+}
+
+// Ping sends an application-level ping and waits for the matching pong.
+func (c *RealtimeWSClient) Ping(ctx context.Context) error {
+	id := c.nextRequestID("ping")
+	ch := make(chan struct{}, 1)
+	c.pingMu.Lock()
+	c.pendingPings[id] = ch
+	c.pingMu.Unlock()
+	defer func() {
+		c.pingMu.Lock()
+		delete(c.pendingPings, id)
+		c.pingMu.Unlock()
+	}()
+
+	if err := c.Send(ctx, &eventEnvelope{Type: "ping", RequestID: id}); err != nil {
+		return err
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clk.After(c.cfg.PingTimeout):
+		return ErrPingTimeout
+	}
+}
+
+// OnMessageNew registers a handler for incoming messages.
+func (c *RealtimeWSClient) OnMessageNew(h func(*MessageNewPayload)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.onMessageNew = append(c.dispatcher.onMessageNew, h)
+}
+
+// OnMessageRead registers a handler for message.read events, fired when
+// another participant posts a read receipt.
+func (c *RealtimeWSClient) OnMessageRead(h func(*ReadReceipt)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.onMessageRead = append(c.dispatcher.onMessageRead, h)
+}
+
+// OnTyping registers a handler for typing indicator events.
+func (c *RealtimeWSClient) OnTyping(h func(*TypingPayload)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.onTyping = append(c.dispatcher.onTyping, h)
+}
+
+// OnPresence registers a handler for presence change events.
+func (c *RealtimeWSClient) OnPresence(h func(*PresencePayload)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.onPresence = append(c.dispatcher.onPresence, h)
+}
+
+// On registers a generic handler for an event type.
+func (c *RealtimeWSClient) On(eventType string, h func(json.RawMessage)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.generic[eventType] = append(c.dispatcher.generic[eventType], h)
+}
+
+// OnAny registers a wildcard handler that fires for every dispatched
+// event regardless of type, e.g. to log or forward all server events
+// without enumerating them. Frames the client consumes internally
+// (authenticated, pong, message.ack) are not dispatched.
+func (c *RealtimeWSClient) OnAny(h func(eventType string, payload json.RawMessage)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.wildcard = append(c.dispatcher.wildcard, h)
+}
+
+// SubscribeMessages returns a channel delivering message.new events in the
+// order they arrive on the wire, for consumers that need ordering — the
+// OnMessageNew callbacks each run on their own goroutine and may observe
+// bursts out of order. buffer bounds the channel (<= 0 uses a default of
+// 64); when the consumer falls behind, events are dropped rather than
+// stalling the read loop, and onDrop (may be nil) observes each one.
+// cancel unregisters the subscription and closes the channel.
+func (c *RealtimeWSClient) SubscribeMessages(buffer int, onDrop func(*MessageNewPayload)) (events <-chan *MessageNewPayload, cancel func()) {
+	return c.dispatcher.subscribeMessages(buffer, onDrop)
+}
+
+// readLoop pumps events off the socket until it drops. It is the sole
+// owner of reconnect scheduling: every teardown short of Disconnect makes
+// the read fail, and exactly one readLoop exists per connection, so at
+// most one reconnect loop ever runs.
+func (c *RealtimeWSClient) readLoop() {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	for {
+		_, data, err := conn.Read(context.Background())
+		if err != nil {
+			c.mu.Lock()
+			// stale means a newer connection already replaced this one;
+			// its own readLoop owns any further reconnects.
+			stale := c.conn != conn
+			wasClosed := c.closed
+			abandoned := c.abandoned
+			if !stale {
+				c.connected = false
+			}
+			c.mu.Unlock()
+			if !stale && !wasClosed && !abandoned && c.cfg.AutoReconnect {
+				go c.scheduleReconnect()
+			}
+			return
+		}
+		c.handleFrame(data)
+	}
+}
+
+// handleFrame decodes one inbound envelope and routes it.
+func (c *RealtimeWSClient) handleFrame(data []byte) {
+	var env eventEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return
+	}
+	switch env.Type {
+	case "pong":
+		c.pingMu.Lock()
+		if ch, ok := c.pendingPings[env.RequestID]; ok {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+		c.pingMu.Unlock()
+	case "authenticated":
+		c.mu.Lock()
+		authCh := c.authCh
+		c.mu.Unlock()
+		if authCh != nil {
+			select {
+			case <-authCh:
+			default:
+				close(authCh)
+			}
+		}
+	case "message.ack":
+		c.resolveAck(&env)
+	default:
+		if env.RequestID != "" {
+			// A message.new echoing one of our own sends settles the
+			// waiting SendMessageAck; it is still dispatched to handlers.
+			c.resolveAck(&env)
+		}
+		c.dispatcher.dispatch(&env)
+	}
+}
+
+// heartbeatLoop pings on an interval until ctx — the owning connection's
+// context, cancelled by teardown — ends. A missed pong is reported through
+// OnHeartbeatMissed and tolerated; only MaxMissedHeartbeats consecutive
+// misses tear the connection down; the dying readLoop then schedules the
+// reconnect, so this loop never races it with a second one.
+func (c *RealtimeWSClient) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.Ping(context.Background()); err != nil {
+			missed++
+			c.notifyHeartbeatMissed(missed)
+			if missed < c.cfg.MaxMissedHeartbeats {
+				continue
+			}
+			c.teardown()
+			return
+		}
+		missed = 0
+	}
+}
+
+// OnHeartbeatMissed registers a callback invoked with the number of
+// consecutive missed pongs each time a heartbeat times out, so callers
+// can observe a degrading connection before it is closed.
+func (c *RealtimeWSClient) OnHeartbeatMissed(h func(consecutive int)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onHeartbeatMissed = append(c.onHeartbeatMissed, h)
+}
+
+// notifyHeartbeatMissed fans a missed heartbeat out to observers.
+func (c *RealtimeWSClient) notifyHeartbeatMissed(consecutive int) {
+	c.mu.Lock()
+	handlers := append([]func(int){}, c.onHeartbeatMissed...)
+	c.mu.Unlock()
+	for _, h := range handlers {
+		h(consecutive)
+	}
+}
+
+// scheduleReconnect retries the connection with exponential backoff. The
+// wait is interruptible: Disconnect cancels rootCtx and aborts any pending
+// attempt immediately.
+func (c *RealtimeWSClient) scheduleReconnect() {
+	for attempt := 0; attempt < c.cfg.MaxReconnectAttempts; attempt++ {
+		select {
+		case <-c.rootCtx.Done():
+			return
+		case <-c.clk.After(backoffDelay(c.cfg.ReconnectBaseDelay, attempt)):
+		}
+		if err := c.connectOnce(c.rootCtx); err == nil {
+			return
+		}
+	}
+}
+
+// abandon tears down a connection whose handshake failed, flagging it so
+// its readLoop leaves the redial to the connectOnce caller's retry loop.
+func (c *RealtimeWSClient) abandon() {
+	c.mu.Lock()
+	c.abandoned = true
+	c.mu.Unlock()
+	c.teardown()
+}
+
+// teardown closes the socket and cancels the connection's heartbeat loop;
+// the connection's readLoop then decides whether a reconnect follows.
+func (c *RealtimeWSClient) teardown() {
+	c.mu.Lock()
+	conn := c.conn
+	c.connected = false
+	cancel := c.connCancel
+	c.connCancel = nil
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if conn != nil {
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+	}
+}
+
+// Disconnect closes the connection and disables reconnection, cancelling
+// any reconnect still waiting out its backoff.
+func (c *RealtimeWSClient) Disconnect() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.rootCancel()
+	c.teardown()
+}
+
+// Close implements io.Closer.
+func (c *RealtimeWSClient) Close() error {
+	c.Disconnect()
+	return nil
+}
+
+// RealtimeSSEClient is a receive-only realtime stream over server-sent
+// events, for environments where WebSockets are unavailable.
+type RealtimeSSEClient struct {
+	c          *Client
+	cfg        RealtimeConfig
+	dispatcher *eventDispatcher
+	clk        clock
+
+	// rootCtx bounds the stream and any reconnects; Disconnect cancels it.
+	rootCtx    context.Context
+	rootCancel context.CancelFunc
+
+	mu        sync.Mutex
+	cancel    context.CancelFunc
+	connected bool
+	closed    bool
+}
+
+// Connect starts the event stream. With AutoReconnect set, the initial
+// request is retried with exponential backoff up to MaxReconnectAttempts,
+// mirroring the WS client.
+func (c *RealtimeSSEClient) Connect(ctx context.Context) error {
+	attempts := connectAttempts(&c.cfg)
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.clk.After(backoffDelay(c.cfg.ReconnectBaseDelay, attempt-1)):
+			}
+		}
+		if lastErr = c.connectOnce(ctx); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("sse connect failed after %d attempts: %w", attempts, lastErr)
+}
+
+// connectOnce performs a single stream request. The stream itself is tied
+// to the client's root context rather than ctx, which only governs the
+// connect attempt.
+func (c *RealtimeSSEClient) connectOnce(ctx context.Context) error {
+	streamCtx, cancel := context.WithCancel(c.rootCtx)
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, c.cfg.URL, nil)
+	if err != nil {
+		cancel()
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		cancel()
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return fmt.Errorf("sse endpoint returned %s", resp.Status)
+	}
+
+	c.mu.Lock()
+	c.cancel = cancel
+	c.connected = true
+	c.closed = false
+	c.mu.Unlock()
+
+	go c.readLoop(resp)
+	return nil
+}
+
+// readLoop parses the SSE stream until it ends.
+func (c *RealtimeSSEClient) readLoop(resp *http.Response) {
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	eventType := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			env := eventEnvelope{Type: eventType, Payload: json.RawMessage(data)}
+			if eventType == "" {
+				// Bare data lines carry the envelope themselves.
+				_ = json.Unmarshal([]byte(data), &env)
+			}
+			c.dispatcher.dispatch(&env)
+		case line == "":
+			eventType = ""
+		}
+	}
+
+	c.mu.Lock()
+	wasClosed := c.closed
+	c.connected = false
+	c.mu.Unlock()
+	if !wasClosed && c.cfg.AutoReconnect {
+		go c.scheduleReconnect()
+	}
+}
+
+// scheduleReconnect retries the stream with exponential backoff,
+// interruptible by Disconnect via the root context.
+func (c *RealtimeSSEClient) scheduleReconnect() {
+	for attempt := 0; attempt < c.cfg.MaxReconnectAttempts; attempt++ {
+		select {
+		case <-c.rootCtx.Done():
+			return
+		case <-c.clk.After(backoffDelay(c.cfg.ReconnectBaseDelay, attempt)):
+		}
+		if err := c.connectOnce(c.rootCtx); err == nil {
+			return
+		}
+	}
+}
+
+// JoinConversation subscribes the authenticated user to a conversation's
+// events. SSE is receive-only, so unlike the WS client this goes over a
+// plain HTTP request; the server then includes the conversation in the
+// stream.
+func (c *RealtimeSSEClient) JoinConversation(ctx context.Context, conversationID string) error {
+	res, err := imCall(ctx, c.c, http.MethodPost, "/api/im/conversations/"+conversationID+"/subscribe", nil, nil)
+	if err != nil {
+		return err
+	}
+	return res.Err()
+}
+
+// LeaveConversation removes the subscription set up by JoinConversation.
+func (c *RealtimeSSEClient) LeaveConversation(ctx context.Context, conversationID string) error {
+	res, err := imCall(ctx, c.c, http.MethodPost, "/api/im/conversations/"+conversationID+"/unsubscribe", nil, nil)
+	if err != nil {
+		return err
+	}
+	return res.Err()
+}
+
+// SubscribeMessages returns an ordered, bounded message.new channel; see
+// RealtimeWSClient.SubscribeMessages for the delivery semantics.
+func (c *RealtimeSSEClient) SubscribeMessages(buffer int, onDrop func(*MessageNewPayload)) (events <-chan *MessageNewPayload, cancel func()) {
+	return c.dispatcher.subscribeMessages(buffer, onDrop)
+}
+
+// OnMessageNew registers a handler for incoming messages.
+func (c *RealtimeSSEClient) OnMessageNew(h func(*MessageNewPayload)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.onMessageNew = append(c.dispatcher.onMessageNew, h)
+}
+
+// OnMessageRead registers a handler for message.read events.
+func (c *RealtimeSSEClient) OnMessageRead(h func(*ReadReceipt)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.onMessageRead = append(c.dispatcher.onMessageRead, h)
+}
+
+// OnTyping registers a handler for typing indicator events.
+func (c *RealtimeSSEClient) OnTyping(h func(*TypingPayload)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.onTyping = append(c.dispatcher.onTyping, h)
+}
+
+// OnPresence registers a handler for presence change events.
+func (c *RealtimeSSEClient) OnPresence(h func(*PresencePayload)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.onPresence = append(c.dispatcher.onPresence, h)
+}
+
+// On registers a generic handler for an event type.
+func (c *RealtimeSSEClient) On(eventType string, h func(json.RawMessage)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.generic[eventType] = append(c.dispatcher.generic[eventType], h)
+}
+
+// OnAny registers a wildcard handler that fires for every dispatched
+// event regardless of type; the SSE counterpart of the WS client's OnAny.
+func (c *RealtimeSSEClient) OnAny(h func(eventType string, payload json.RawMessage)) {
+	c.dispatcher.mu.Lock()
+	defer c.dispatcher.mu.Unlock()
+	c.dispatcher.wildcard = append(c.dispatcher.wildcard, h)
+}
+
+// Disconnect stops the stream and disables reconnection, cancelling any
+// reconnect still waiting out its backoff.
+func (c *RealtimeSSEClient) Disconnect() {
+	c.mu.Lock()
+	c.closed = true
+	c.connected = false
+	cancel := c.cancel
+	c.mu.Unlock()
+	c.rootCancel()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Close implements io.Closer.
+func (c *RealtimeSSEClient) Close() error {
+	c.Disconnect()
+	return nil
+}
+
+// mustJSON marshals a value the SDK fully controls.
+func mustJSON(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,559 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestSSEConnectRetriesWithAutoReconnect(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	sse, err := client.IM().Realtime.ConnectSSE(context.Background(), &RealtimeConfig{
+		AutoReconnect:      true,
+		ReconnectBaseDelay: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("ConnectSSE: %v", err)
+	}
+	defer sse.Disconnect()
+
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("expected 3 connect attempts, got %d", got)
+	}
+}
+
+func TestSSEConnectFailsFastWithoutAutoReconnect(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	if _, err := client.IM().Realtime.ConnectSSE(context.Background(), nil); err == nil {
+		t.Fatal("expected connect error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected a single connect attempt, got %d", got)
+	}
+}
+
+func TestSSEJoinConversationSubscribesOverHTTP(t *testing.T) {
+	subscribed := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/im/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+		case "/api/im/conversations/c1/subscribe":
+			if r.Method != http.MethodPost {
+				t.Errorf("subscribe used %s, want POST", r.Method)
+			}
+			subscribed <- r.Header.Get("Authorization")
+			w.Write([]byte(`{"success":true}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	sse, err := client.IM().Realtime.ConnectSSE(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ConnectSSE: %v", err)
+	}
+	defer sse.Disconnect()
+
+	if err := sse.JoinConversation(context.Background(), "c1"); err != nil {
+		t.Fatalf("JoinConversation: %v", err)
+	}
+	select {
+	case auth := <-subscribed:
+		if auth != "Bearer k" {
+			t.Fatalf("subscribe sent Authorization %q", auth)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscribe endpoint was not called")
+	}
+}
+
+// echoWSServer accepts one WebSocket connection, answers auth and ping
+// commands, and counts every other well-formed command frame it reads.
+func echoWSServer(t *testing.T, commands *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx := r.Context()
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var env eventEnvelope
+			if err := json.Unmarshal(data, &env); err != nil {
+				t.Errorf("received corrupted frame: %v", err)
+				return
+			}
+			switch env.Type {
+			case "auth":
+				_ = conn.Write(ctx, websocket.MessageText, []byte(`{"type":"authenticated"}`))
+			case "ping":
+				pong, _ := json.Marshal(eventEnvelope{Type: "pong", RequestID: env.RequestID})
+				_ = conn.Write(ctx, websocket.MessageText, pong)
+			default:
+				atomic.AddInt32(commands, 1)
+			}
+		}
+	}))
+}
+
+func TestWSDialAuthenticatesViaHeaderNotQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer k" {
+			t.Errorf("dial Authorization = %q, want Bearer k", got)
+		}
+		if r.URL.Query().Get("token") != "" {
+			t.Error("token leaked into the dial query string")
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx := r.Context()
+		for {
+			if _, _, err := conn.Read(ctx); err != nil {
+				return
+			}
+			_ = conn.Write(ctx, websocket.MessageText, []byte(`{"type":"authenticated"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ws, err := client.IM().Realtime.ConnectWS(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ConnectWS: %v", err)
+	}
+	ws.Disconnect()
+}
+
+func TestWSDialQueryParamAuthFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("token"); got != "k" {
+			t.Errorf("dial token query = %q, want k", got)
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx := r.Context()
+		for {
+			if _, _, err := conn.Read(ctx); err != nil {
+				return
+			}
+			_ = conn.Write(ctx, websocket.MessageText, []byte(`{"type":"authenticated"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ws, err := client.IM().Realtime.ConnectWS(context.Background(), &RealtimeConfig{QueryParamAuth: true})
+	if err != nil {
+		t.Fatalf("ConnectWS: %v", err)
+	}
+	ws.Disconnect()
+}
+
+func TestWSHeartbeatToleratesMissedPongs(t *testing.T) {
+	// Answers auth but swallows pings so every heartbeat times out.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx := r.Context()
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var env eventEnvelope
+			if json.Unmarshal(data, &env) == nil && env.Type == "auth" {
+				_ = conn.Write(ctx, websocket.MessageText, []byte(`{"type":"authenticated"}`))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ws, err := client.IM().Realtime.ConnectWS(context.Background(), &RealtimeConfig{
+		HeartbeatInterval:   15 * time.Millisecond,
+		PingTimeout:         5 * time.Millisecond,
+		MaxMissedHeartbeats: 3,
+	})
+	if err != nil {
+		t.Fatalf("ConnectWS: %v", err)
+	}
+	defer ws.Disconnect()
+
+	misses := make(chan int, 8)
+	ws.OnHeartbeatMissed(func(consecutive int) { misses <- consecutive })
+
+	waitMiss := func(want int) {
+		t.Helper()
+		select {
+		case got := <-misses:
+			if got != want {
+				t.Fatalf("consecutive misses = %d, want %d", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for miss %d", want)
+		}
+	}
+
+	waitMiss(1)
+	ws.mu.Lock()
+	connected := ws.connected
+	ws.mu.Unlock()
+	if !connected {
+		t.Fatal("connection torn down after a single missed pong")
+	}
+
+	waitMiss(2)
+	waitMiss(3)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		ws.mu.Lock()
+		connected := ws.connected
+		ws.mu.Unlock()
+		if !connected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("connection not torn down after max missed pongs")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWSConcurrentSendsDoNotCorruptFrames(t *testing.T) {
+	var commands int32
+	srv := echoWSServer(t, &commands)
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ws, err := client.IM().Realtime.ConnectWS(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ConnectWS: %v", err)
+	}
+	defer ws.Disconnect()
+
+	const perKind = 20
+	var wg sync.WaitGroup
+	for i := 0; i < perKind; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_ = ws.SendMessage(context.Background(), "c1", "hello", "")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = ws.StartTyping(context.Background(), "c1")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = ws.Ping(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&commands) < 2*perKind {
+		if time.Now().After(deadline) {
+			t.Fatalf("server saw %d commands, want %d", atomic.LoadInt32(&commands), 2*perKind)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWSSendMessageWithOptionsCarriesThreadAndMetadata(t *testing.T) {
+	frames := make(chan eventEnvelope, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx := r.Context()
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var env eventEnvelope
+			if json.Unmarshal(data, &env) != nil {
+				continue
+			}
+			if env.Type == "auth" {
+				_ = conn.Write(ctx, websocket.MessageText, []byte(`{"type":"authenticated"}`))
+				continue
+			}
+			frames <- env
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ws, err := client.IM().Realtime.ConnectWS(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ConnectWS: %v", err)
+	}
+	defer ws.Disconnect()
+
+	err = ws.SendMessageWithOptions(context.Background(), "c1", "reply", &IMSendOptions{
+		ParentID: "m0",
+		Metadata: map[string]any{"lang": "en"},
+	})
+	if err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+
+	select {
+	case env := <-frames:
+		var payload map[string]any
+		if err := json.Unmarshal(env.Payload, &payload); err != nil {
+			t.Fatalf("payload: %v", err)
+		}
+		if payload["conversationId"] != "c1" || payload["parentId"] != "m0" {
+			t.Fatalf("payload = %v, missing conversation or parent", payload)
+		}
+		meta, _ := payload["metadata"].(map[string]any)
+		if meta["lang"] != "en" {
+			t.Fatalf("metadata = %v, want lang=en", meta)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not receive the message frame")
+	}
+}
+
+func TestSubscribeMessagesDeliversInOrderAndDropsWhenFull(t *testing.T) {
+	d := newEventDispatcher(nil, nil)
+
+	var dropped []*MessageNewPayload
+	events, cancel := d.subscribeMessages(2, func(p *MessageNewPayload) { dropped = append(dropped, p) })
+
+	for i := 1; i <= 3; i++ {
+		payload := fmt.Sprintf(`{"conversationId":"c1","message":{"id":"m%d"}}`, i)
+		d.dispatch(&eventEnvelope{Type: RealtimeEventMessageNew, Payload: json.RawMessage(payload)})
+	}
+
+	// The buffer holds the first two in arrival order; the third is
+	// dropped and reported.
+	if got := (<-events).Message.ID; got != "m1" {
+		t.Fatalf("first event = %s, want m1", got)
+	}
+	if got := (<-events).Message.ID; got != "m2" {
+		t.Fatalf("second event = %s, want m2", got)
+	}
+	if len(dropped) != 1 || dropped[0].Message.ID != "m3" {
+		t.Fatalf("dropped = %+v, want exactly m3", dropped)
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Fatal("channel not closed after cancel")
+	}
+	// Dispatching after cancel must not panic on the closed channel.
+	d.dispatch(&eventEnvelope{Type: RealtimeEventMessageNew, Payload: json.RawMessage(`{"conversationId":"c1","message":{"id":"m4"}}`)})
+}
+
+func TestDispatcherWildcardSeesAllEvents(t *testing.T) {
+	d := newEventDispatcher(nil, nil)
+	events := make(chan string, 4)
+	d.mu.Lock()
+	d.wildcard = append(d.wildcard, func(eventType string, payload json.RawMessage) {
+		events <- eventType
+	})
+	d.mu.Unlock()
+
+	d.dispatch(&eventEnvelope{Type: "message.new", Payload: json.RawMessage(`{"conversationId":"c1","message":{"id":"m1"}}`)})
+	d.dispatch(&eventEnvelope{Type: "credits.low", Payload: json.RawMessage(`{"balance":1}`)})
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-events:
+			seen[ev] = true
+		case <-time.After(2 * time.Second):
+			t.Fatal("wildcard handler did not fire for all events")
+		}
+	}
+	if !seen["message.new"] || !seen["credits.low"] {
+		t.Fatalf("wildcard saw %v, want both typed and unknown events", seen)
+	}
+}
+
+func TestDispatcherRoutesMessageReadToTypedHandler(t *testing.T) {
+	d := newEventDispatcher(nil, nil)
+	receipts := make(chan *ReadReceipt, 1)
+	d.mu.Lock()
+	d.onMessageRead = append(d.onMessageRead, func(r *ReadReceipt) {
+		receipts <- r
+	})
+	d.mu.Unlock()
+
+	d.dispatch(&eventEnvelope{Type: RealtimeEventMessageRead,
+		Payload: json.RawMessage(`{"messageId":"m1","userId":"u2"}`)})
+
+	select {
+	case r := <-receipts:
+		if r.MessageID != "m1" || r.UserID != "u2" {
+			t.Fatalf("receipt = %+v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message.read handler did not fire")
+	}
+}
+
+func TestWSReconnectDoesNotLeakHeartbeatLoops(t *testing.T) {
+	var pings int32
+	// Answers auth and pong across any number of connections, counting
+	// every ping it sees regardless of which connection sent it.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx := r.Context()
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var env eventEnvelope
+			if json.Unmarshal(data, &env) != nil {
+				return
+			}
+			switch env.Type {
+			case "auth":
+				_ = conn.Write(ctx, websocket.MessageText, []byte(`{"type":"authenticated"}`))
+			case "ping":
+				atomic.AddInt32(&pings, 1)
+				pong, _ := json.Marshal(eventEnvelope{Type: "pong", RequestID: env.RequestID})
+				_ = conn.Write(ctx, websocket.MessageText, pong)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ws, err := client.IM().Realtime.ConnectWS(context.Background(), &RealtimeConfig{
+		HeartbeatInterval: 20 * time.Millisecond,
+		PingTimeout:       time.Second,
+	})
+	if err != nil {
+		t.Fatalf("ConnectWS: %v", err)
+	}
+	defer ws.Disconnect()
+
+	// Each cycle used to leave the previous heartbeat loop running, so
+	// after four reconnects five loops would be pinging concurrently.
+	for i := 0; i < 4; i++ {
+		ws.teardown()
+		if err := ws.connectOnce(context.Background()); err != nil {
+			t.Fatalf("reconnect %d: %v", i, err)
+		}
+	}
+
+	atomic.StoreInt32(&pings, 0)
+	time.Sleep(300 * time.Millisecond)
+	if got := atomic.LoadInt32(&pings); got > 35 {
+		t.Fatalf("observed %d pings in 300ms at a 20ms interval; stale heartbeat loops still running", got)
+	}
+}
+
+func TestWSMissedHeartbeatTeardownReconnectsExactlyOnce(t *testing.T) {
+	var conns int32
+	// Starves the first connection of pongs so the heartbeat loop hits its
+	// miss limit; every later connection gets healthy pongs. The heartbeat
+	// teardown and the failing readLoop used to each schedule a reconnect,
+	// so the server would see extra dials.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&conns, 1)
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx := r.Context()
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var env eventEnvelope
+			if json.Unmarshal(data, &env) != nil {
+				return
+			}
+			switch env.Type {
+			case "auth":
+				_ = conn.Write(ctx, websocket.MessageText, []byte(`{"type":"authenticated"}`))
+			case "ping":
+				if n == 1 {
+					continue
+				}
+				pong, _ := json.Marshal(eventEnvelope{Type: "pong", RequestID: env.RequestID})
+				_ = conn.Write(ctx, websocket.MessageText, pong)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	ws, err := client.IM().Realtime.ConnectWS(context.Background(), &RealtimeConfig{
+		AutoReconnect:       true,
+		ReconnectBaseDelay:  5 * time.Millisecond,
+		HeartbeatInterval:   15 * time.Millisecond,
+		PingTimeout:         5 * time.Millisecond,
+		MaxMissedHeartbeats: 2,
+	})
+	if err != nil {
+		t.Fatalf("ConnectWS: %v", err)
+	}
+	defer ws.Disconnect()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&conns) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("miss-limit teardown never reconnected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// Give any duplicate reconnect loop ample time to dial before counting.
+	time.Sleep(250 * time.Millisecond)
+	if got := atomic.LoadInt32(&conns); got != 2 {
+		t.Fatalf("server saw %d connections, want 2 (exactly one reconnect)", got)
+	}
+}
@@ -0,0 +1,125 @@
+package prismer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// tokenRefreshMargin is how long before expiry the token is renewed.
+	tokenRefreshMargin = time.Minute
+
+	// tokenRefreshRetryDelay is the wait after a failed refresh attempt.
+	tokenRefreshRetryDelay = 30 * time.Second
+)
+
+// WithAutoRefresh keeps the client's IM token fresh: it parses the JWT
+// expiry, renews the token via Account.RefreshToken shortly before it
+// lapses, and installs the new token with SetToken. Refresh failures are
+// reported to onError (which may be nil) and retried. The background
+// refresher stops when the client is closed.
+func WithAutoRefresh(onError func(error)) ClientOption {
+	return func(c *Client) {
+		c.autoRefresh = true
+		c.refreshErr = onError
+	}
+}
+
+// tokenExpiry extracts the exp claim from a JWT without verifying it.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// tokenRefresher renews the client token in the background.
+type tokenRefresher struct {
+	c      *Client
+	clk    clock
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newTokenRefresher(c *Client) *tokenRefresher {
+	r := &tokenRefresher{c: c, clk: realClock{}}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	return r
+}
+
+// Close stops the refresher. It is registered with the owning client so
+// client shutdown fans out to it.
+func (r *tokenRefresher) Close() error {
+	r.cancel()
+	return nil
+}
+
+// reportErr surfaces a refresh failure to the configured callback.
+func (r *tokenRefresher) reportErr(err error) {
+	if r.c.refreshErr != nil {
+		r.c.refreshErr(err)
+	}
+}
+
+// run sleeps until shortly before the current token expires, refreshes it,
+// and repeats with the renewed token's expiry.
+func (r *tokenRefresher) run() {
+	for {
+		exp, ok := tokenExpiry(r.c.token())
+		if !ok {
+			r.reportErr(errors.New("prismer: token has no parseable expiry; auto-refresh disabled"))
+			return
+		}
+		if wait := exp.Add(-tokenRefreshMargin).Sub(r.clk.Now()); wait > 0 {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-r.clk.After(wait):
+			}
+		}
+		if err := r.refreshOnce(); err != nil {
+			r.reportErr(err)
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-r.clk.After(tokenRefreshRetryDelay):
+			}
+		}
+	}
+}
+
+// refreshOnce rotates the token through Account.RefreshToken.
+func (r *tokenRefresher) refreshOnce() error {
+	res, err := r.c.im.Account.RefreshToken(r.ctx)
+	if err != nil {
+		return err
+	}
+	if err := res.Err(); err != nil {
+		return err
+	}
+	var reg IMRegisterResult
+	if err := res.Decode(&reg); err != nil {
+		return fmt.Errorf("failed to decode refreshed token: %w", err)
+	}
+	if reg.Token == "" {
+		return errors.New("prismer: refresh returned an empty token")
+	}
+	r.c.SetToken(reg.Token)
+	return nil
+}
@@ -0,0 +1,53 @@
+package prismer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testJWT fabricates an unsigned JWT with the given expiry.
+func testJWT(exp time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return header + "." + payload + ".sig"
+}
+
+func TestTokenExpiryParsesJWT(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	got, ok := tokenExpiry(testJWT(exp))
+	if !ok || !got.Equal(exp) {
+		t.Fatalf("tokenExpiry = %v, %t; want %v", got, ok, exp)
+	}
+	if _, ok := tokenExpiry("not-a-jwt"); ok {
+		t.Fatal("tokenExpiry accepted a non-JWT token")
+	}
+}
+
+func TestAutoRefreshRotatesTokenBeforeExpiry(t *testing.T) {
+	fresh := testJWT(time.Now().Add(2 * time.Hour))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/im/token/refresh" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprintf(w, `{"success":true,"data":{"token":"%s","userId":"u1","username":"a"}}`, fresh)
+	}))
+	defer srv.Close()
+
+	// Token already inside the refresh margin, so the refresher fires
+	// immediately.
+	client := NewClient(testJWT(time.Now().Add(time.Second)),
+		WithBaseURL(srv.URL),
+		WithAutoRefresh(func(err error) { t.Errorf("refresh error: %v", err) }))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.token() != fresh {
+		if time.Now().After(deadline) {
+			t.Fatal("token was not rotated before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
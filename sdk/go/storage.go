@@ -0,0 +1,417 @@
+package prismer
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoredMessage is a message as kept in the offline cache. Pending messages
+// are optimistic local writes that have not been confirmed by the server yet.
+type StoredMessage struct {
+	ID             string         `json:"id"`
+	ClientID       string         `json:"clientId,omitempty"`
+	ConversationID string         `json:"conversationId"`
+	SenderID       string         `json:"senderId,omitempty"`
+	Content        string         `json:"content"`
+	Type           string         `json:"type,omitempty"`
+	ParentID       string         `json:"parentId,omitempty"`
+	Metadata       map[string]any `json:"metadata,omitempty"`
+	CreatedAt      string         `json:"createdAt,omitempty"`
+	ReadAt         string         `json:"readAt,omitempty"`
+	Pending        bool           `json:"pending,omitempty"`
+	SyncSeq        int64          `json:"syncSeq,omitempty"`
+}
+
+// Created parses CreatedAt into a time.Time, zero when absent.
+func (m *StoredMessage) Created() (time.Time, error) {
+	return parseTimestamp(m.CreatedAt)
+}
+
+// StoredConversation is a conversation as kept in the offline cache.
+type StoredConversation struct {
+	ID            string `json:"id"`
+	Type          string `json:"type,omitempty"`
+	Title         string `json:"title,omitempty"`
+	UnreadCount   int    `json:"unreadCount,omitempty"`
+	LastMessageAt string `json:"lastMessageAt,omitempty"`
+	SyncSeq       int64  `json:"syncSeq,omitempty"`
+}
+
+// Outbox operation statuses.
+const (
+	opStatusPending = "pending"
+	opStatusFailed  = "failed"
+)
+
+// OutboxOp is a queued write waiting to be flushed to the server.
+type OutboxOp struct {
+	ID             string         `json:"id"`
+	Type           string         `json:"type"`
+	Method         string         `json:"method"`
+	Path           string         `json:"path"`
+	Payload        map[string]any `json:"payload,omitempty"`
+	ConversationID string         `json:"conversationId,omitempty"`
+	ClientID       string         `json:"clientId,omitempty"`
+	CreatedAt      string         `json:"createdAt"`
+	Retries        int            `json:"retries"`
+	Status         string         `json:"status"`
+	LastError      string         `json:"lastError,omitempty"`
+
+	// NextAttemptAt holds the op back until its retry backoff has elapsed;
+	// the zero value means the op is immediately deliverable.
+	NextAttemptAt time.Time `json:"nextAttemptAt,omitempty"`
+}
+
+// Outbox retry backoff: 1s, 2s, 4s, ... capped at one minute.
+const (
+	outboxBackoffBase = time.Second
+	outboxBackoffMax  = time.Minute
+)
+
+// outboxBackoff computes the delay before the next delivery attempt of an
+// op that has failed retries times.
+func outboxBackoff(retries int) time.Duration {
+	if retries < 1 {
+		return 0
+	}
+	d := outboxBackoffBase << (retries - 1)
+	if d > outboxBackoffMax || d <= 0 {
+		return outboxBackoffMax
+	}
+	return d
+}
+
+// Storage is the persistence backend of an OfflineManager: a message and
+// conversation cache, a write outbox, and the sync cursor. MemoryStorage
+// is the default implementation; supply a custom one through
+// OfflineOptions.Storage to survive process restarts.
+type Storage interface {
+	GetMessage(id string) *StoredMessage
+	PutMessage(m *StoredMessage)
+	PutMessages(msgs []*StoredMessage)
+	DeleteMessage(id string)
+	GetMessages(conversationID string, limit int, before string) []*StoredMessage
+	SearchMessages(query, conversationID string) []*StoredMessage
+
+	GetConversation(id string) *StoredConversation
+	PutConversation(c *StoredConversation)
+	GetConversations() []*StoredConversation
+
+	Enqueue(op *OutboxOp)
+	// DequeueReady must return copies of the stored ops: callers keep
+	// reading them while Ack/Nack mutate the originals.
+	DequeueReady(limit int) []*OutboxOp
+	// Pending returns copies of every outstanding op, including those
+	// still waiting out a retry backoff or held behind another op in the
+	// same conversation; DequeueReady returns only the deliverable
+	// subset.
+	Pending() []*OutboxOp
+	Ack(opID string)
+	Nack(opID string, maxRetries int, errMsg string)
+	Fail(opID, errMsg string)
+	// DeadLettered returns copies of the ops that failed permanently,
+	// oldest first; Requeue puts one of them back into rotation.
+	DeadLettered() []*OutboxOp
+	Requeue(opID string) bool
+	PendingOps() int
+
+	GetCursor() string
+	SetCursor(cursor string)
+}
+
+// MemoryStorage is the in-memory cache and outbox used by OfflineManager.
+// All state is lost when the process exits.
+type MemoryStorage struct {
+	mu            sync.RWMutex
+	messages      map[string]*StoredMessage
+	conversations map[string]*StoredConversation
+	outbox        map[string]*OutboxOp
+	cursor        string
+}
+
+var _ Storage = (*MemoryStorage)(nil)
+
+// NewMemoryStorage returns an empty in-memory store.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		messages:      make(map[string]*StoredMessage),
+		conversations: make(map[string]*StoredConversation),
+		outbox:        make(map[string]*OutboxOp),
+	}
+}
+
+// GetMessage returns the cached message with the given ID, or nil.
+func (s *MemoryStorage) GetMessage(id string) *StoredMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.messages[id]
+}
+
+// PutMessage inserts or replaces one cached message.
+func (s *MemoryStorage) PutMessage(m *StoredMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[m.ID] = m
+}
+
+// PutMessages inserts or replaces a batch of cached messages.
+func (s *MemoryStorage) PutMessages(msgs []*StoredMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range msgs {
+		s.messages[m.ID] = m
+	}
+}
+
+// DeleteMessage removes a cached message.
+func (s *MemoryStorage) DeleteMessage(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, id)
+}
+
+// GetMessages returns up to limit cached messages for a conversation, oldest
+// first. When before is set, only messages created strictly before that
+// timestamp (or with that message ID's timestamp) are returned.
+func (s *MemoryStorage) GetMessages(conversationID string, limit int, before string) []*StoredMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*StoredMessage
+	for _, m := range s.messages {
+		if m.ConversationID != conversationID {
+			continue
+		}
+		if before != "" && !timestampLess(m.CreatedAt, before) {
+			continue
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return timestampLess(out[i].CreatedAt, out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+// SearchMessages returns cached messages whose content contains query,
+// optionally restricted to one conversation.
+func (s *MemoryStorage) SearchMessages(query, conversationID string) []*StoredMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	needle := strings.ToLower(query)
+	var out []*StoredMessage
+	for _, m := range s.messages {
+		if conversationID != "" && m.ConversationID != conversationID {
+			continue
+		}
+		if strings.Contains(strings.ToLower(m.Content), needle) {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return timestampLess(out[i].CreatedAt, out[j].CreatedAt) })
+	return out
+}
+
+// GetConversation returns one cached conversation, or nil.
+func (s *MemoryStorage) GetConversation(id string) *StoredConversation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.conversations[id]
+}
+
+// PutConversation inserts or replaces a cached conversation.
+func (s *MemoryStorage) PutConversation(c *StoredConversation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conversations[c.ID] = c
+}
+
+// GetConversations returns all cached conversations, most recently active
+// first.
+func (s *MemoryStorage) GetConversations() []*StoredConversation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*StoredConversation, 0, len(s.conversations))
+	for _, c := range s.conversations {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastMessageAt > out[j].LastMessageAt })
+	return out
+}
+
+// Enqueue appends a write operation to the outbox.
+func (s *MemoryStorage) Enqueue(op *OutboxOp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op.Status = opStatusPending
+	s.outbox[op.ID] = op
+}
+
+// DequeueReady returns up to limit pending outbox operations whose retry
+// backoff has elapsed, oldest first. The returned ops are copies: Ack and
+// Nack mutate the stored ops concurrently with the flusher reading them,
+// so handing out the live pointers would be a data race. Payload is set
+// once at enqueue time and never modified, so sharing it is safe.
+//
+// Delivery is serialized per conversation: an op is held back while an
+// earlier pending op for the same ConversationID is still in the outbox
+// (typically waiting out its retry backoff), so two sends to the same
+// chat can never arrive reordered. A permanently failed op stops
+// blocking its successors.
+func (s *MemoryStorage) DequeueReady(limit int) []*OutboxOp {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pending []*OutboxOp
+	for _, op := range s.outbox {
+		if op.Status == opStatusPending {
+			pending = append(pending, op)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return timestampLess(pending[i].CreatedAt, pending[j].CreatedAt) })
+
+	now := time.Now()
+	blocked := make(map[string]bool)
+	var out []*OutboxOp
+	for _, op := range pending {
+		if op.ConversationID != "" {
+			if blocked[op.ConversationID] {
+				continue
+			}
+			// Whether dispatched now or still backing off, this op is the
+			// conversation's head of line; everything behind it waits.
+			blocked[op.ConversationID] = true
+		}
+		if !op.NextAttemptAt.IsZero() && op.NextAttemptAt.After(now) {
+			continue
+		}
+		cp := *op
+		out = append(out, &cp)
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// Pending returns copies of all outstanding operations, oldest first,
+// regardless of retry backoff or per-conversation ordering. Conflict
+// resolution uses it to see ops DequeueReady is currently holding back.
+func (s *MemoryStorage) Pending() []*OutboxOp {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*OutboxOp
+	for _, op := range s.outbox {
+		if op.Status == opStatusPending {
+			cp := *op
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return timestampLess(out[i].CreatedAt, out[j].CreatedAt) })
+	return out
+}
+
+// Ack removes a successfully delivered operation from the outbox.
+func (s *MemoryStorage) Ack(opID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.outbox, opID)
+}
+
+// Nack records a failed delivery attempt and schedules the next one with
+// exponential backoff. Once the operation has been retried maxRetries
+// times its status becomes "failed" and DequeueReady stops returning it.
+func (s *MemoryStorage) Nack(opID string, maxRetries int, errMsg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.outbox[opID]
+	if !ok {
+		return
+	}
+	op.Retries++
+	op.LastError = errMsg
+	if op.Retries >= maxRetries {
+		op.Status = opStatusFailed
+		return
+	}
+	op.NextAttemptAt = time.Now().Add(outboxBackoff(op.Retries))
+}
+
+// Fail marks an operation permanently failed regardless of retry count.
+func (s *MemoryStorage) Fail(opID, errMsg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.outbox[opID]
+	if !ok {
+		return
+	}
+	op.Status = opStatusFailed
+	op.LastError = errMsg
+}
+
+// DeadLettered returns copies of the permanently failed outbox operations,
+// oldest first. They stay in the outbox until requeued or acked so a
+// failed write is never silently lost.
+func (s *MemoryStorage) DeadLettered() []*OutboxOp {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*OutboxOp
+	for _, op := range s.outbox {
+		if op.Status != opStatusFailed {
+			continue
+		}
+		cp := *op
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return timestampLess(out[i].CreatedAt, out[j].CreatedAt) })
+	return out
+}
+
+// Requeue puts a dead-lettered operation back into rotation with a clean
+// retry budget. It reports whether opID named a failed op.
+func (s *MemoryStorage) Requeue(opID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.outbox[opID]
+	if !ok || op.Status != opStatusFailed {
+		return false
+	}
+	op.Status = opStatusPending
+	op.Retries = 0
+	op.LastError = ""
+	op.NextAttemptAt = time.Time{}
+	return true
+}
+
+// PendingOps reports how many operations are waiting to be flushed.
+func (s *MemoryStorage) PendingOps() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, op := range s.outbox {
+		if op.Status == opStatusPending {
+			n++
+		}
+	}
+	return n
+}
+
+// GetCursor returns the persisted sync cursor.
+func (s *MemoryStorage) GetCursor() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cursor
+}
+
+// SetCursor persists the sync cursor.
+func (s *MemoryStorage) SetCursor(cursor string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursor = cursor
+}
@@ -0,0 +1,204 @@
+package prismer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileStateName is the snapshot file FileStorage keeps inside its
+// directory.
+const fileStateName = "offline-state.json"
+
+// fileState is the on-disk snapshot of a FileStorage.
+type fileState struct {
+	Messages      map[string]*StoredMessage      `json:"messages,omitempty"`
+	Conversations map[string]*StoredConversation `json:"conversations,omitempty"`
+	Outbox        map[string]*OutboxOp           `json:"outbox,omitempty"`
+	Cursor        string                         `json:"cursor,omitempty"`
+}
+
+// FileStorage is a Storage backend that persists the cache, outbox and
+// sync cursor to a JSON snapshot in a directory, so queued writes survive
+// a restart. Every mutation rewrites the snapshot atomically (write to a
+// temp file, then rename); reads are served from memory.
+type FileStorage struct {
+	mem *MemoryStorage
+	dir string
+
+	// saveMu serializes snapshot writes; mem's own lock protects state.
+	saveMu sync.Mutex
+}
+
+var _ Storage = (*FileStorage)(nil)
+
+// NewFileStorage opens (or creates) a file-backed store in dir, loading
+// any snapshot a previous process left behind.
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	s := &FileStorage{mem: NewMemoryStorage(), dir: dir}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load restores state from the snapshot file, if present.
+func (s *FileStorage) load() error {
+	raw, err := os.ReadFile(filepath.Join(s.dir, fileStateName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read storage snapshot: %w", err)
+	}
+	var state fileState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return fmt.Errorf("failed to decode storage snapshot: %w", err)
+	}
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	for id, m := range state.Messages {
+		s.mem.messages[id] = m
+	}
+	for id, c := range state.Conversations {
+		s.mem.conversations[id] = c
+	}
+	for id, op := range state.Outbox {
+		s.mem.outbox[id] = op
+	}
+	s.mem.cursor = state.Cursor
+	return nil
+}
+
+// persist writes the current state to disk. Snapshot errors are swallowed
+// deliberately: the in-memory state stays authoritative and the next
+// mutation retries the write.
+func (s *FileStorage) persist() {
+	s.mem.mu.RLock()
+	data, err := json.Marshal(&fileState{
+		Messages:      s.mem.messages,
+		Conversations: s.mem.conversations,
+		Outbox:        s.mem.outbox,
+		Cursor:        s.mem.cursor,
+	})
+	s.mem.mu.RUnlock()
+	if err != nil {
+		return
+	}
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	tmp := filepath.Join(s.dir, fileStateName+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, filepath.Join(s.dir, fileStateName))
+}
+
+// GetMessage returns the cached message with the given ID, or nil.
+func (s *FileStorage) GetMessage(id string) *StoredMessage { return s.mem.GetMessage(id) }
+
+// PutMessage inserts or replaces one cached message.
+func (s *FileStorage) PutMessage(m *StoredMessage) {
+	s.mem.PutMessage(m)
+	s.persist()
+}
+
+// PutMessages inserts or replaces a batch of cached messages.
+func (s *FileStorage) PutMessages(msgs []*StoredMessage) {
+	s.mem.PutMessages(msgs)
+	s.persist()
+}
+
+// DeleteMessage removes a cached message.
+func (s *FileStorage) DeleteMessage(id string) {
+	s.mem.DeleteMessage(id)
+	s.persist()
+}
+
+// GetMessages returns up to limit cached messages for a conversation,
+// oldest first.
+func (s *FileStorage) GetMessages(conversationID string, limit int, before string) []*StoredMessage {
+	return s.mem.GetMessages(conversationID, limit, before)
+}
+
+// SearchMessages returns cached messages whose content contains query.
+func (s *FileStorage) SearchMessages(query, conversationID string) []*StoredMessage {
+	return s.mem.SearchMessages(query, conversationID)
+}
+
+// GetConversation returns one cached conversation, or nil.
+func (s *FileStorage) GetConversation(id string) *StoredConversation {
+	return s.mem.GetConversation(id)
+}
+
+// PutConversation inserts or replaces a cached conversation.
+func (s *FileStorage) PutConversation(c *StoredConversation) {
+	s.mem.PutConversation(c)
+	s.persist()
+}
+
+// GetConversations returns all cached conversations, most recently active
+// first.
+func (s *FileStorage) GetConversations() []*StoredConversation { return s.mem.GetConversations() }
+
+// Enqueue appends a write operation to the outbox and makes it durable.
+func (s *FileStorage) Enqueue(op *OutboxOp) {
+	s.mem.Enqueue(op)
+	s.persist()
+}
+
+// DequeueReady returns up to limit pending outbox operations, oldest first.
+func (s *FileStorage) DequeueReady(limit int) []*OutboxOp { return s.mem.DequeueReady(limit) }
+
+// Pending returns copies of all outstanding operations regardless of
+// readiness.
+func (s *FileStorage) Pending() []*OutboxOp { return s.mem.Pending() }
+
+// Ack removes a successfully delivered operation from the outbox.
+func (s *FileStorage) Ack(opID string) {
+	s.mem.Ack(opID)
+	s.persist()
+}
+
+// Nack records a failed delivery attempt.
+func (s *FileStorage) Nack(opID string, maxRetries int, errMsg string) {
+	s.mem.Nack(opID, maxRetries, errMsg)
+	s.persist()
+}
+
+// Fail marks an operation permanently failed regardless of retry count.
+func (s *FileStorage) Fail(opID, errMsg string) {
+	s.mem.Fail(opID, errMsg)
+	s.persist()
+}
+
+// DeadLettered returns copies of the permanently failed operations.
+func (s *FileStorage) DeadLettered() []*OutboxOp { return s.mem.DeadLettered() }
+
+// Requeue puts a dead-lettered operation back into rotation.
+func (s *FileStorage) Requeue(opID string) bool {
+	ok := s.mem.Requeue(opID)
+	if ok {
+		s.persist()
+	}
+	return ok
+}
+
+// PendingOps reports how many operations are waiting to be flushed.
+func (s *FileStorage) PendingOps() int { return s.mem.PendingOps() }
+
+// GetCursor returns the persisted sync cursor.
+func (s *FileStorage) GetCursor() string { return s.mem.GetCursor() }
+
+// SetCursor persists the sync cursor.
+func (s *FileStorage) SetCursor(cursor string) {
+	s.mem.SetCursor(cursor)
+	s.persist()
+}
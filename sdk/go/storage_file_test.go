@@ -0,0 +1,67 @@
+package prismer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestFileStorageOutboxSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+	m := newOfflineManager(&fakeDoer{}, &OfflineOptions{Storage: first})
+	m.SetOnline(false)
+	if _, err := m.Dispatch(context.Background(), http.MethodPost, "/api/im/direct/u1/messages", nil,
+		map[string]any{"content": "queued while offline"}); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if got := first.PendingOps(); got != 1 {
+		t.Fatalf("pending ops before restart = %d, want 1", got)
+	}
+
+	// Simulate a restart: a fresh FileStorage over the same directory must
+	// see the queued op and cached optimistic message.
+	second, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage after restart: %v", err)
+	}
+	if got := second.PendingOps(); got != 1 {
+		t.Fatalf("pending ops after restart = %d, want 1", got)
+	}
+	if msgs := second.SearchMessages("queued", ""); len(msgs) != 1 {
+		t.Fatalf("cached messages after restart = %d, want 1", len(msgs))
+	}
+
+	fake := &fakeDoer{steps: []fakeStep{
+		{body: `{"success":true,"data":{"id":"m1","conversationId":"c1","content":"queued while offline"}}`},
+	}}
+	restarted := newOfflineManager(fake, &OfflineOptions{Storage: second})
+	restarted.Flush(context.Background())
+	if got := second.PendingOps(); got != 0 {
+		t.Fatalf("pending ops after flush = %d, want 0", got)
+	}
+	if fake.calls != 1 {
+		t.Fatalf("delivery attempts = %d, want 1", fake.calls)
+	}
+}
+
+func TestFileStorageCursorPersists(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+	s.SetCursor("seq-42")
+
+	reopened, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+	if got := reopened.GetCursor(); got != "seq-42" {
+		t.Fatalf("cursor after restart = %q, want %q", got, "seq-42")
+	}
+}
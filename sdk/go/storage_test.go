@@ -0,0 +1,101 @@
+package prismer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNackSchedulesExponentialBackoff(t *testing.T) {
+	s := NewMemoryStorage()
+	s.Enqueue(&OutboxOp{ID: "op1", CreatedAt: nowRFC3339()})
+
+	s.Nack("op1", 5, "boom")
+	if got := len(s.DequeueReady(0)); got != 0 {
+		t.Fatalf("op dequeued during backoff window, got %d ops", got)
+	}
+	if got := s.PendingOps(); got != 1 {
+		t.Fatalf("op no longer pending: %d", got)
+	}
+
+	// Simulate the backoff window elapsing.
+	s.mu.Lock()
+	s.outbox["op1"].NextAttemptAt = time.Now().Add(-time.Millisecond)
+	s.mu.Unlock()
+	if got := len(s.DequeueReady(0)); got != 1 {
+		t.Fatalf("op not dequeued after backoff elapsed, got %d ops", got)
+	}
+}
+
+func TestDequeueReadyReturnsCopies(t *testing.T) {
+	s := NewMemoryStorage()
+	s.Enqueue(&OutboxOp{ID: "op1", CreatedAt: nowRFC3339()})
+
+	// Reading the dequeued op must be safe while Ack/Nack mutate the
+	// stored one; run with -race to catch regressions.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			for _, op := range s.DequeueReady(0) {
+				_ = op.Retries
+				_ = op.Status
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		s.Nack("op1", 1000, "boom")
+		s.mu.Lock()
+		s.outbox["op1"].NextAttemptAt = time.Time{}
+		s.mu.Unlock()
+	}
+	<-done
+
+	ops := s.DequeueReady(0)
+	if len(ops) != 1 {
+		t.Fatalf("got %d ops, want 1", len(ops))
+	}
+	ops[0].Status = "mutated"
+	if got := s.outbox["op1"].Status; got != opStatusPending {
+		t.Fatalf("mutating a dequeued op leaked into storage: status %q", got)
+	}
+}
+
+func TestOutboxBackoffGrowsAndCaps(t *testing.T) {
+	if got := outboxBackoff(1); got != time.Second {
+		t.Errorf("backoff(1) = %v, want 1s", got)
+	}
+	if got := outboxBackoff(3); got != 4*time.Second {
+		t.Errorf("backoff(3) = %v, want 4s", got)
+	}
+	if got := outboxBackoff(20); got != outboxBackoffMax {
+		t.Errorf("backoff(20) = %v, want cap %v", got, outboxBackoffMax)
+	}
+}
+
+func TestGetMessagesOrdersMixedTimestampFormats(t *testing.T) {
+	ms := NewMemoryStorage()
+	// Same instants in different RFC3339 renderings: lexical comparison
+	// would order the +02:00 rendering after the Z ones.
+	ms.PutMessages([]*StoredMessage{
+		{ID: "m3", ConversationID: "c1", CreatedAt: "2026-01-01T10:00:02Z"},
+		{ID: "m1", ConversationID: "c1", CreatedAt: "2026-01-01T12:00:00.5+02:00"},
+		{ID: "m2", ConversationID: "c1", CreatedAt: "2026-01-01T10:00:01.250Z"},
+	})
+
+	got := ms.GetMessages("c1", 0, "")
+	if len(got) != 3 || got[0].ID != "m1" || got[1].ID != "m2" || got[2].ID != "m3" {
+		ids := make([]string, len(got))
+		for i, m := range got {
+			ids[i] = m.ID
+		}
+		t.Fatalf("order = %v, want [m1 m2 m3]", ids)
+	}
+
+	created, err := got[0].Created()
+	if err != nil {
+		t.Fatalf("Created: %v", err)
+	}
+	if want := time.Date(2026, 1, 1, 10, 0, 0, 500000000, time.UTC); !created.Equal(want) {
+		t.Fatalf("Created = %v, want %v", created, want)
+	}
+}
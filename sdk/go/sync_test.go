@@ -0,0 +1,217 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// syncPage builds a sync envelope with the given event seqs.
+func syncPage(seqs []int64, nextSince string, hasMore bool) map[string]any {
+	events := make([]map[string]any, 0, len(seqs))
+	for _, seq := range seqs {
+		events = append(events, map[string]any{
+			"seq":  seq,
+			"type": "message.new",
+			"data": map[string]any{"id": generateID("msg"), "conversationId": "conv-1", "content": "hi"},
+		})
+	}
+	return map[string]any{
+		"success": true,
+		"data":    map[string]any{"events": events, "nextSince": nextSince, "hasMore": hasMore},
+	}
+}
+
+func TestSyncEmitsGapEvent(t *testing.T) {
+	pages := []map[string]any{
+		syncPage([]int64{1, 2}, "2", true),
+		syncPage([]int64{5}, "5", false), // seq 3 and 4 dropped
+	}
+	call := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/im/sync" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		page := pages[call]
+		if call < len(pages)-1 {
+			call++
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	m := NewOfflineManager(NewClient("k", WithBaseURL(srv.URL)), nil)
+	var gaps []SyncGapEvent
+	m.OnSyncGap(func(ev SyncGapEvent) { gaps = append(gaps, ev) })
+
+	if err := m.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(gaps) != 1 {
+		t.Fatalf("got %d gap events, want 1", len(gaps))
+	}
+	if gaps[0].ExpectedSeq != 3 || gaps[0].GotSeq != 5 {
+		t.Errorf("gap payload = %+v, want ExpectedSeq 3 GotSeq 5", gaps[0])
+	}
+	if m.storage.GetCursor() != "5" {
+		t.Errorf("cursor = %q, want 5", m.storage.GetCursor())
+	}
+}
+
+func TestSyncResyncOnGapRefetches(t *testing.T) {
+	var sinceSeen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("since")
+		sinceSeen = append(sinceSeen, since)
+		var page map[string]any
+		switch len(sinceSeen) {
+		case 1:
+			page = syncPage([]int64{1, 2}, "2", true)
+		case 2:
+			page = syncPage([]int64{7}, "7", false) // gap triggers resync
+		default:
+			page = syncPage([]int64{1, 2, 7}, "7", false)
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	m := NewOfflineManager(NewClient("k", WithBaseURL(srv.URL)), &OfflineOptions{ResyncOnGap: true})
+	if err := m.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(sinceSeen) != 3 {
+		t.Fatalf("got %d sync calls, want 3 (page, gap, refetch): %v", len(sinceSeen), sinceSeen)
+	}
+	if sinceSeen[2] != "" {
+		t.Errorf("refetch used cursor %q, want empty", sinceSeen[2])
+	}
+}
+
+func TestClientConflictStrategyKeepsPendingMessage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := map[string]any{
+			"success": true,
+			"data": map[string]any{
+				"events": []map[string]any{{
+					"seq":  1,
+					"type": "message.edit",
+					"data": map[string]any{"id": "m1", "conversationId": "c1", "content": "server edit"},
+				}},
+				"hasMore": false,
+			},
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	m := NewOfflineManager(NewClient("k", WithBaseURL(srv.URL)), &OfflineOptions{
+		ConflictStrategy: ConflictClient,
+	})
+	m.storage.PutMessage(&StoredMessage{
+		ID:             "m1",
+		ConversationID: "c1",
+		Content:        "local draft",
+		Pending:        true,
+	})
+
+	var conflicts []SyncConflictEvent
+	m.OnSyncConflict(func(ev SyncConflictEvent) { conflicts = append(conflicts, ev) })
+
+	if err := m.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := m.storage.GetMessage("m1").Content; got != "local draft" {
+		t.Fatalf("content = %q, local pending change was clobbered", got)
+	}
+	if len(conflicts) != 1 || conflicts[0].Kind != "message" || conflicts[0].ID != "m1" {
+		t.Fatalf("conflicts = %+v, want one message conflict for m1", conflicts)
+	}
+}
+
+func TestMessageReadSyncEventSetsReadAt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := map[string]any{
+			"success": true,
+			"data": map[string]any{
+				"events": []map[string]any{{
+					"seq":  1,
+					"type": "message.read",
+					"data": map[string]any{"conversationId": "c1", "messageId": "m1", "userId": "u2", "readAt": "2026-01-02T03:04:05Z"},
+				}},
+				"hasMore": false,
+			},
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	m := NewOfflineManager(NewClient("k", WithBaseURL(srv.URL)), nil)
+	m.storage.PutMessage(&StoredMessage{ID: "m1", ConversationID: "c1", Content: "hi"})
+
+	if err := m.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := m.storage.GetMessage("m1").ReadAt; got != "2026-01-02T03:04:05Z" {
+		t.Fatalf("ReadAt = %q, want receipt timestamp", got)
+	}
+}
+
+func TestOpTypeForMessageReadPath(t *testing.T) {
+	got := opTypeForRequest(http.MethodPost, "/api/im/conversations/c1/messages/m1/read")
+	if got != "message.read" {
+		t.Fatalf("opType = %q, want message.read", got)
+	}
+	if got := opTypeForRequest(http.MethodPost, "/api/im/conversations/c1/read"); got != "conversation.read" {
+		t.Fatalf("opType = %q, want conversation.read", got)
+	}
+}
+
+func TestClientConflictStrategyProtectsOpInBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := map[string]any{
+			"success": true,
+			"data": map[string]any{
+				"events": []map[string]any{{
+					"seq":  1,
+					"type": "message.edit",
+					"data": map[string]any{"id": "m1", "conversationId": "c1", "content": "server edit"},
+				}},
+				"hasMore": false,
+			},
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	m := NewOfflineManager(NewClient("k", WithBaseURL(srv.URL)), &OfflineOptions{
+		ConflictStrategy: ConflictClient,
+	})
+	// The message itself is already confirmed (not pending); only the
+	// outbox op editing it marks the local change. Its delivery just
+	// failed and is waiting out a backoff, so DequeueReady hides it.
+	m.storage.PutMessage(&StoredMessage{ID: "m1", ConversationID: "c1", Content: "local edit"})
+	m.storage.Enqueue(&OutboxOp{
+		ID:             "op1",
+		Type:           "message.edit",
+		Method:         "PUT",
+		Path:           "/api/im/conversations/c1/messages/m1",
+		ConversationID: "c1",
+		CreatedAt:      "2026-01-01T00:00:00Z",
+		Retries:        1,
+		NextAttemptAt:  time.Now().Add(time.Minute),
+	})
+	if got := len(m.storage.DequeueReady(0)); got != 0 {
+		t.Fatalf("op in backoff unexpectedly deliverable (%d ready)", got)
+	}
+
+	if err := m.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := m.storage.GetMessage("m1").Content; got != "local edit" {
+		t.Fatalf("content = %q, in-flight local edit was clobbered", got)
+	}
+}
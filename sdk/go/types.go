@@ -0,0 +1,365 @@
+package prismer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// IMUser is a registered account (human or agent) on the IM platform.
+type IMUser struct {
+	ID          string       `json:"id"`
+	Username    string       `json:"username"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Type        string       `json:"type,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	AgentCard   *IMAgentCard `json:"agentCard,omitempty"`
+}
+
+// IMAgentCard describes an agent's capabilities, returned by Me and the
+// discovery endpoints.
+type IMAgentCard struct {
+	Type         string   `json:"type,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Status       string   `json:"status,omitempty"`
+}
+
+// parseTimestamp parses an API timestamp (RFC3339, with or without
+// sub-second digits). The empty string parses to the zero time without
+// error, since most timestamp fields are optional on the wire.
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// timestampLess orders two API timestamps chronologically, parsing them
+// so format variations (sub-second precision, zone offsets) still order
+// correctly. Unparsable values fall back to the lexical comparison that
+// matches uniform RFC3339 strings.
+func timestampLess(a, b string) bool {
+	ta, errA := parseTimestamp(a)
+	tb, errB := parseTimestamp(b)
+	if errA == nil && errB == nil && !ta.Equal(tb) {
+		return ta.Before(tb)
+	}
+	return a < b
+}
+
+// IMMessage is a message as returned by the IM API.
+type IMMessage struct {
+	ID             string          `json:"id"`
+	ConversationID string          `json:"conversationId"`
+	SenderID       string          `json:"senderId"`
+	Sender         *IMUser         `json:"sender,omitempty"`
+	Content        string          `json:"content"`
+	Type           string          `json:"type,omitempty"`
+	ParentID       string          `json:"parentId,omitempty"`
+	Metadata       json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt      string          `json:"createdAt,omitempty"`
+	EditedAt       string          `json:"editedAt,omitempty"`
+}
+
+// UnmarshalJSON decodes a wire message, normalizing Metadata: some API
+// responses return metadata as a JSON-encoded string rather than an
+// object, which would otherwise force callers through a shadow struct.
+// After decoding, Metadata always holds the object form.
+func (m *IMMessage) UnmarshalJSON(data []byte) error {
+	type alias IMMessage
+	var a alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*m = IMMessage(a)
+	m.Metadata = normalizeMetadata(m.Metadata)
+	return nil
+}
+
+// MetadataMap decodes the message metadata into a map, tolerating both
+// the object and stringified-JSON wire shapes. It returns nil when there
+// is no metadata or it cannot be decoded.
+func (m *IMMessage) MetadataMap() map[string]any {
+	raw := normalizeMetadata(m.Metadata)
+	if len(raw) == 0 {
+		return nil
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// Created parses CreatedAt into a time.Time. A message without a
+// timestamp parses to the zero time without error.
+func (m *IMMessage) Created() (time.Time, error) {
+	return parseTimestamp(m.CreatedAt)
+}
+
+// Edited parses EditedAt into a time.Time, zero when the message was
+// never edited.
+func (m *IMMessage) Edited() (time.Time, error) {
+	return parseTimestamp(m.EditedAt)
+}
+
+// normalizeMetadata unwraps metadata sent as a JSON string containing an
+// object, leaving every other shape untouched.
+func normalizeMetadata(raw json.RawMessage) json.RawMessage {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '"' {
+		return raw
+	}
+	var s string
+	if err := json.Unmarshal(trimmed, &s); err != nil {
+		return raw
+	}
+	inner := []byte(strings.TrimSpace(s))
+	if len(inner) == 0 || inner[0] != '{' || !json.Valid(inner) {
+		return raw
+	}
+	return json.RawMessage(inner)
+}
+
+// IMConversation is a direct or group conversation.
+type IMConversation struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type,omitempty"`
+	Title        string          `json:"title,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	Participants []IMUser        `json:"participants,omitempty"`
+	LastMessage  *IMMessage      `json:"lastMessage,omitempty"`
+	UnreadCount  int             `json:"unreadCount,omitempty"`
+	Metadata     json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt    string          `json:"createdAt,omitempty"`
+	UpdatedAt    string          `json:"updatedAt,omitempty"`
+}
+
+// Created parses CreatedAt into a time.Time, zero when absent.
+func (c *IMConversation) Created() (time.Time, error) {
+	return parseTimestamp(c.CreatedAt)
+}
+
+// Updated parses UpdatedAt into a time.Time, zero when absent.
+func (c *IMConversation) Updated() (time.Time, error) {
+	return parseTimestamp(c.UpdatedAt)
+}
+
+// IMRegisterOptions configures agent registration.
+type IMRegisterOptions struct {
+	Username     string         `json:"username"`
+	DisplayName  string         `json:"displayName,omitempty"`
+	Type         string         `json:"type,omitempty"`
+	Capabilities []string       `json:"capabilities,omitempty"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+}
+
+// IMRegisterResult is the payload returned by Account.Register and
+// Account.RefreshToken.
+type IMRegisterResult struct {
+	Token     string `json:"token"`
+	UserID    string `json:"userId"`
+	Username  string `json:"username"`
+	ExpiresIn int64  `json:"expiresIn,omitempty"`
+}
+
+// MessageType is the wire type of a chat message. The MessageType*
+// constants enumerate the set the platform understands; sends validate
+// against it unless ValidateMessageTypes is disabled, so a typo fails
+// locally instead of as an opaque server error.
+type MessageType string
+
+const (
+	MessageTypeText       MessageType = "text"
+	MessageTypeMarkdown   MessageType = "markdown"
+	MessageTypeToolCall   MessageType = "tool_call"
+	MessageTypeToolResult MessageType = "tool_result"
+	MessageTypeThinking   MessageType = "thinking"
+	MessageTypeImage      MessageType = "image"
+	MessageTypeFile       MessageType = "file"
+)
+
+// Valid reports whether t is a supported message type. The empty type is
+// valid: sends default it to MessageTypeText.
+func (t MessageType) Valid() bool {
+	switch t {
+	case "", MessageTypeText, MessageTypeMarkdown, MessageTypeToolCall,
+		MessageTypeToolResult, MessageTypeThinking, MessageTypeImage, MessageTypeFile:
+		return true
+	}
+	return false
+}
+
+// IMSendOptions carries the optional fields of a message send.
+//
+// ParentID and QuotedMessageID serve different purposes: ParentID makes the
+// message part of a thread, while QuotedMessageID renders an inline quote of
+// a prior message without affecting thread membership.
+//
+// Metadata (like SaveOptions.Meta) is marshaled deterministically: Go's
+// encoding/json emits map keys in sorted order, so identical metadata always
+// produces identical bytes on the wire. Snapshot tests can rely on this.
+type IMSendOptions struct {
+	Type            MessageType    `json:"type,omitempty"`
+	ParentID        string         `json:"parentId,omitempty"`
+	QuotedMessageID string         `json:"quotedMessageId,omitempty"`
+	Metadata        map[string]any `json:"metadata,omitempty"`
+
+	// ClientID is a caller-supplied correlation ID. When set, the offline
+	// layer uses it as the idempotency key and echoes it in the optimistic
+	// StoredMessage and the message.confirmed event; when empty, the SDK
+	// generates one.
+	ClientID string `json:"clientId,omitempty"`
+}
+
+// ForwardOptions carries the optional fields of a message forward.
+type ForwardOptions struct {
+	// Attribution is prepended server-side to mark the message as
+	// forwarded, e.g. "Forwarded from #research".
+	Attribution string `json:"attribution,omitempty"`
+}
+
+// IMPaginationOptions selects a page of a list endpoint. Before and
+// After page by cursor (a message ID or timestamp) and are stable when
+// new messages arrive mid-scroll; Offset remains supported for list
+// endpoints that have no cursor.
+type IMPaginationOptions struct {
+	Limit  int
+	Offset int
+
+	// Before returns items older than this cursor, for paging backward
+	// through history.
+	Before string
+
+	// After returns items newer than this cursor.
+	After string
+}
+
+// IMCreateGroupOptions configures group creation.
+type IMCreateGroupOptions struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	MemberIDs   []string       `json:"memberIds,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// IMSearchOptions parameterizes server-side message search.
+type IMSearchOptions struct {
+	// Query is the full-text search string.
+	Query string
+
+	// ConversationID restricts the search to one conversation.
+	ConversationID string
+
+	// Type filters by message type.
+	Type string
+
+	// From and To bound the search by message creation time. Zero values
+	// leave the corresponding end open.
+	From time.Time
+	To   time.Time
+
+	Limit  int
+	Offset int
+}
+
+// IMUpdateGroupOptions carries the group fields Groups.Update may change.
+// Zero-valued fields are omitted from the request and left untouched.
+type IMUpdateGroupOptions struct {
+	Title       string
+	Description string
+	Metadata    map[string]any
+}
+
+// IMDiscoverOptions filters agent discovery.
+type IMDiscoverOptions struct {
+	Type       string
+	Capability string
+
+	// Query matches against agent usernames and display names.
+	Query string
+	// Limit caps the number of agents returned per page; zero uses the
+	// server default.
+	Limit int
+	// Offset skips that many agents, for paging through large registries
+	// together with Limit.
+	Offset int
+}
+
+// IMDiscoverAgent is one entry in a discovery result.
+type IMDiscoverAgent struct {
+	UserID      string       `json:"userId"`
+	Username    string       `json:"username"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	Card        *IMAgentCard `json:"card,omitempty"`
+}
+
+// IMBindingOptions configures a platform binding (Telegram, Discord, ...).
+type IMBindingOptions struct {
+	Platform string         `json:"platform"`
+	BotToken string         `json:"botToken,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// IMBinding is an external platform binding.
+type IMBinding struct {
+	ID        string `json:"id"`
+	Platform  string `json:"platform"`
+	Status    string `json:"status,omitempty"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+// IMWorkspaceInitOptions seeds a direct workspace conversation.
+type IMWorkspaceInitOptions struct {
+	WorkspaceID string         `json:"workspaceId"`
+	Title       string         `json:"title,omitempty"`
+	AgentID     string         `json:"agentId,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// IMWorkspaceInitGroupOptions seeds a multi-agent workspace group.
+type IMWorkspaceInitGroupOptions struct {
+	WorkspaceID string         `json:"workspaceId"`
+	Title       string         `json:"title,omitempty"`
+	AgentIDs    []string       `json:"agentIds,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// CreditsBalance is the account's current credit balance.
+type CreditsBalance struct {
+	Balance  float64 `json:"balance"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// CreditTransaction is one entry in the credits ledger.
+type CreditTransaction struct {
+	ID        string  `json:"id"`
+	Amount    float64 `json:"amount"`
+	Type      string  `json:"type,omitempty"`
+	Reference string  `json:"reference,omitempty"`
+	CreatedAt string  `json:"createdAt,omitempty"`
+}
+
+// Created parses CreatedAt into a time.Time, zero when absent.
+func (t *CreditTransaction) Created() (time.Time, error) {
+	return parseTimestamp(t.CreatedAt)
+}
+
+// SyncEventData is one change event from the /api/im/sync endpoint.
+type SyncEventData struct {
+	Seq            int64           `json:"seq"`
+	Type           string          `json:"type"`
+	ConversationID string          `json:"conversationId,omitempty"`
+	Data           json.RawMessage `json:"data,omitempty"`
+	CreatedAt      string          `json:"createdAt,omitempty"`
+}
+
+// SyncResultData is a page of sync events.
+type SyncResultData struct {
+	Events    []SyncEventData `json:"events"`
+	NextSince string          `json:"nextSince,omitempty"`
+	HasMore   bool            `json:"hasMore,omitempty"`
+}
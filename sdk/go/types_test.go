@@ -0,0 +1,110 @@
+package prismer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// The SDK documents that metadata and meta maps marshal deterministically
+// (encoding/json sorts map keys). This pins that guarantee so snapshot
+// tests built on the SDK stay stable.
+func TestMetadataMarshalingIsDeterministic(t *testing.T) {
+	build := func(keys []string) map[string]any {
+		meta := make(map[string]any, len(keys))
+		for _, k := range keys {
+			meta[k] = len(k)
+		}
+		return meta
+	}
+
+	a, err := json.Marshal(sendPayload("hi", &IMSendOptions{Metadata: build([]string{"zeta", "alpha", "mid"})}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(sendPayload("hi", &IMSendOptions{Metadata: build([]string{"mid", "zeta", "alpha"})}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("metadata marshaling not deterministic:\n%s\n%s", a, b)
+	}
+
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(a, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"alpha":5,"mid":3,"zeta":4}`
+	if string(decoded["metadata"]) != want {
+		t.Errorf("metadata = %s, want keys sorted: %s", decoded["metadata"], want)
+	}
+}
+
+func TestIMMessageMetadataToleratesBothWireShapes(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+	}{
+		{name: "object", body: `{"id":"m1","conversationId":"c1","senderId":"u1","content":"hi","metadata":{"lang":"en"}}`},
+		{name: "stringified", body: `{"id":"m1","conversationId":"c1","senderId":"u1","content":"hi","metadata":"{\"lang\":\"en\"}"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var msg IMMessage
+			if err := json.Unmarshal([]byte(tc.body), &msg); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if string(msg.Metadata) != `{"lang":"en"}` {
+				t.Fatalf("Metadata = %s, want normalized object", msg.Metadata)
+			}
+			if got := msg.MetadataMap(); got["lang"] != "en" {
+				t.Fatalf("MetadataMap = %v, want lang=en", got)
+			}
+		})
+	}
+
+	// A plain string that is not JSON stays as-is rather than erroring.
+	var msg IMMessage
+	if err := json.Unmarshal([]byte(`{"id":"m1","metadata":"just a note"}`), &msg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if string(msg.Metadata) != `"just a note"` {
+		t.Fatalf("Metadata = %s, want untouched string", msg.Metadata)
+	}
+	if msg.MetadataMap() != nil {
+		t.Fatalf("MetadataMap = %v, want nil for non-object metadata", msg.MetadataMap())
+	}
+}
+
+func TestAPIErrorPreservesDetailsAndFieldErrors(t *testing.T) {
+	var e APIError
+	body := `{"code":"VALIDATION_ERROR","message":"invalid input","details":{"url":"must be absolute","title":"required"}}`
+	if err := json.Unmarshal([]byte(body), &e); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if e.Code != "VALIDATION_ERROR" {
+		t.Fatalf("Code = %q", e.Code)
+	}
+	fields := e.FieldErrors()
+	if fields["url"] != "must be absolute" || fields["title"] != "required" {
+		t.Fatalf("FieldErrors = %v", fields)
+	}
+
+	// The plain string form keeps details nil.
+	var s APIError
+	if err := json.Unmarshal([]byte(`"boom"`), &s); err != nil {
+		t.Fatalf("Unmarshal string form: %v", err)
+	}
+	if s.Code != "UNKNOWN" || s.Details != nil || s.FieldErrors() != nil {
+		t.Fatalf("string form = %+v", s)
+	}
+
+	// Non-map details fall back to nil rather than erroring.
+	var n APIError
+	if err := json.Unmarshal([]byte(`{"code":"X","message":"m","details":[1,2]}`), &n); err != nil {
+		t.Fatalf("Unmarshal array details: %v", err)
+	}
+	if n.FieldErrors() != nil {
+		t.Fatalf("FieldErrors = %v, want nil for non-map details", n.FieldErrors())
+	}
+}
@@ -0,0 +1,546 @@
+package prismer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUploadAttempts  = 3
+	defaultUploadBaseDelay = time.Second
+)
+
+// UploadOptions configures a file upload.
+type UploadOptions struct {
+	// ContentType of the file. Defaults to application/octet-stream.
+	ContentType string
+
+	// MaxAttempts bounds how often each pipeline stage (presign, byte
+	// upload, confirm) is tried. Defaults to 3. The stages retry
+	// independently: a flaky confirm does not re-upload the bytes.
+	MaxAttempts int
+
+	// BaseDelay is the first backoff delay between attempts, doubled per
+	// attempt. Defaults to one second.
+	BaseDelay time.Duration
+
+	// OnProgress, when set, is called with (sent, total) bytes as the
+	// upload body is consumed, so a CLI or UI can render live progress
+	// instead of a single jump to 100%. A retried byte upload restarts the
+	// count from zero. The callback runs on the upload goroutine and must
+	// not block.
+	OnProgress func(sent, total int64)
+
+	// Preflight validates the file against the server's quota and MIME
+	// allow-list (see ValidateUpload) before the presign round-trip, so a
+	// doomed upload fails fast with a specific error.
+	Preflight bool
+}
+
+func (o *UploadOptions) normalize() {
+	if o.ContentType == "" {
+		o.ContentType = "application/octet-stream"
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultUploadAttempts
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = defaultUploadBaseDelay
+	}
+}
+
+// UploadResult describes a confirmed file upload.
+type UploadResult struct {
+	FileID   string `json:"fileId"`
+	URL      string `json:"url,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+// presignData is the server's answer to a presign request.
+type presignData struct {
+	FileID    string            `json:"fileId"`
+	UploadURL string            `json:"uploadUrl"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// FilesClient uploads files for sharing in conversations.
+type FilesClient struct {
+	c *Client
+
+	limitsMu sync.Mutex
+	limits   *UploadLimits
+}
+
+// UploadLimits is the server's upload policy for the caller's tier:
+// the per-file size cap, the storage quota still available, and the
+// accepted MIME types (empty means anything).
+type UploadLimits struct {
+	MaxFileSize    int64    `json:"maxFileSize"`
+	RemainingQuota int64    `json:"remainingQuota"`
+	AllowedTypes   []string `json:"allowedTypes,omitempty"`
+}
+
+// Limits fetches the caller's upload policy, caching it on the client so
+// repeated pre-flights cost one round-trip total. Pass the result of a
+// previous call through InvalidateLimits to refresh after large uploads.
+func (f *FilesClient) Limits(ctx context.Context) (*UploadLimits, error) {
+	f.limitsMu.Lock()
+	defer f.limitsMu.Unlock()
+	if f.limits != nil {
+		return f.limits, nil
+	}
+	res, err := imCall(ctx, f.c, http.MethodGet, "/api/im/files/limits", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var l UploadLimits
+	if err := res.Decode(&l); err != nil {
+		return nil, fmt.Errorf("failed to decode limits response: %w", err)
+	}
+	f.limits = &l
+	return f.limits, nil
+}
+
+// InvalidateLimits drops the cached upload policy, so the next
+// pre-flight re-fetches remaining quota.
+func (f *FilesClient) InvalidateLimits() {
+	f.limitsMu.Lock()
+	f.limits = nil
+	f.limitsMu.Unlock()
+}
+
+// ValidateUpload checks a prospective upload against the server's upload
+// policy before any presign round-trip, failing fast with
+// ErrFileTooLarge, ErrQuotaExceeded or ErrTypeNotAllowed instead of a
+// late confirm error. Upload runs this automatically when
+// UploadOptions.Preflight is set.
+func (f *FilesClient) ValidateUpload(ctx context.Context, size int64, contentType string) error {
+	l, err := f.Limits(ctx)
+	if err != nil {
+		return fmt.Errorf("preflight failed: %w", err)
+	}
+	if l.MaxFileSize > 0 && size > l.MaxFileSize {
+		return fmt.Errorf("%w: %d bytes exceeds the %d byte per-file limit", ErrFileTooLarge, size, l.MaxFileSize)
+	}
+	if l.RemainingQuota > 0 && size > l.RemainingQuota {
+		return fmt.Errorf("%w: %d bytes does not fit in the remaining %d byte quota", ErrQuotaExceeded, size, l.RemainingQuota)
+	}
+	if len(l.AllowedTypes) > 0 {
+		allowed := false
+		for _, t := range l.AllowedTypes {
+			if strings.EqualFold(t, contentType) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s is not in the server allow-list %v", ErrTypeNotAllowed, contentType, l.AllowedTypes)
+		}
+	}
+	return nil
+}
+
+// UploadFile reads a local file and runs it through the presign → upload →
+// confirm pipeline.
+func (f *FilesClient) UploadFile(ctx context.Context, filePath string, opts *UploadOptions) (*UploadResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return f.Upload(ctx, filepath.Base(filePath), data, opts)
+}
+
+// Upload runs in-memory content through the presign → upload → confirm
+// pipeline. Each stage is retried independently with exponential backoff,
+// so a transient confirm failure does not waste an already-completed byte
+// upload.
+func (f *FilesClient) Upload(ctx context.Context, filename string, data []byte, opts *UploadOptions) (*UploadResult, error) {
+	o := UploadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	o.normalize()
+
+	if o.Preflight {
+		if err := f.ValidateUpload(ctx, int64(len(data)), o.ContentType); err != nil {
+			return nil, err
+		}
+	}
+
+	var presigned *presignData
+	err := retryStage(ctx, o.MaxAttempts, o.BaseDelay, func() error {
+		var err error
+		presigned, err = f.presign(ctx, filename, int64(len(data)), o.ContentType)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("presign failed: %w", err)
+	}
+
+	err = retryStage(ctx, o.MaxAttempts, o.BaseDelay, func() error {
+		return f.uploadBytes(ctx, presigned, data, o.ContentType, o.OnProgress)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload failed: %w", err)
+	}
+
+	var result *UploadResult
+	err = retryStage(ctx, o.MaxAttempts, o.BaseDelay, func() error {
+		var err error
+		result, err = f.confirm(ctx, presigned.FileID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("confirm failed: %w", err)
+	}
+	return result, nil
+}
+
+// presign asks the server for an upload slot.
+func (f *FilesClient) presign(ctx context.Context, filename string, size int64, contentType string) (*presignData, error) {
+	res, err := imCall(ctx, f.c, http.MethodPost, "/api/im/files/presign", nil, map[string]any{
+		"filename":    filename,
+		"size":        size,
+		"contentType": contentType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var p presignData
+	if err := res.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to decode presign response: %w", err)
+	}
+	return &p, nil
+}
+
+// progressReader wraps an upload body and reports cumulative bytes read
+// to a callback, so progress reflects the transport actually consuming
+// the body rather than a single notification at the end.
+type progressReader struct {
+	r        io.Reader
+	total    int64
+	sent     int64
+	progress func(sent, total int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.sent += int64(n)
+		pr.progress(pr.sent, pr.total)
+	}
+	return n, err
+}
+
+// uploadBytes PUTs the content to the presigned URL, which may live on
+// external storage outside the deployment.
+func (f *FilesClient) uploadBytes(ctx context.Context, p *presignData, data []byte, contentType string, progress func(sent, total int64)) error {
+	var body io.Reader = bytes.NewReader(data)
+	if progress != nil {
+		body = &progressReader{r: body, total: int64(len(data)), progress: progress}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.UploadURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to build upload request: %w", err)
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", f.c.userAgent)
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := f.c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+	return nil
+}
+
+// confirm finalizes the upload and returns the file record.
+func (f *FilesClient) confirm(ctx context.Context, fileID string) (*UploadResult, error) {
+	res, err := imCall(ctx, f.c, http.MethodPost, "/api/im/files/"+fileID+"/confirm", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var r UploadResult
+	if err := res.Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode confirm response: %w", err)
+	}
+	if r.FileID == "" {
+		r.FileID = fileID
+	}
+	return &r, nil
+}
+
+// defaultPartSize is the multipart chunk size when MultipartOptions does
+// not set one.
+const defaultPartSize int64 = 8 << 20 // 8 MiB
+
+// UploadPart identifies one completed part of a multipart upload, as
+// acknowledged by the storage backend.
+type UploadPart struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+}
+
+// MultipartOptions configures UploadMultipart. UploadID and
+// CompletedParts resume an earlier failed upload: parts already in the
+// list are skipped instead of re-uploaded.
+type MultipartOptions struct {
+	UploadOptions
+
+	// PartSize is the chunk size in bytes. Defaults to 8 MiB.
+	PartSize int64
+
+	// UploadID resumes the multipart session of a previous failed
+	// attempt, as reported by MultipartUploadError.
+	UploadID string
+
+	// CompletedParts are parts already uploaded in a previous attempt,
+	// skipped on resume.
+	CompletedParts []UploadPart
+}
+
+// MultipartUploadError reports a failed multipart upload together with
+// the parts that did complete, so the caller can resume by passing
+// UploadID and CompletedParts back in MultipartOptions instead of
+// restarting from scratch.
+type MultipartUploadError struct {
+	FileID         string
+	UploadID       string
+	CompletedParts []UploadPart
+	Err            error
+}
+
+func (e *MultipartUploadError) Error() string {
+	return fmt.Sprintf("prismer: multipart upload %s failed after %d part(s): %v", e.UploadID, len(e.CompletedParts), e.Err)
+}
+
+func (e *MultipartUploadError) Unwrap() error { return e.Err }
+
+// multipartPresignData is the server's answer to a multipart presign
+// request: one upload URL per part, in part order.
+type multipartPresignData struct {
+	FileID   string            `json:"fileId"`
+	UploadID string            `json:"uploadId"`
+	PartURLs []string          `json:"partUrls"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+// UploadMultipart uploads content in parts, retrying each part
+// independently with exponential backoff. On failure the returned error
+// is a *MultipartUploadError carrying the parts that did complete;
+// passing its UploadID and CompletedParts back in opts resumes the
+// upload without re-sending those bytes. Presigned part URLs are
+// re-issued on resume, so an expired session is re-presigned rather than
+// failing.
+func (f *FilesClient) UploadMultipart(ctx context.Context, filename string, data []byte, opts *MultipartOptions) (*UploadResult, error) {
+	o := MultipartOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	o.normalize()
+	if o.PartSize <= 0 {
+		o.PartSize = defaultPartSize
+	}
+
+	var presigned *multipartPresignData
+	err := retryStage(ctx, o.MaxAttempts, o.BaseDelay, func() error {
+		var err error
+		presigned, err = f.presignMultipart(ctx, filename, int64(len(data)), o.ContentType, o.PartSize, o.UploadID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("presign failed: %w", err)
+	}
+
+	completed := make(map[int]string, len(o.CompletedParts))
+	for _, p := range o.CompletedParts {
+		completed[p.PartNumber] = p.ETag
+	}
+	total := int64(len(data))
+	var sent int64
+	for n := range presigned.PartURLs {
+		if _, ok := completed[n+1]; ok {
+			sent += partLen(total, o.PartSize, n)
+		}
+	}
+
+	fail := func(err error) *MultipartUploadError {
+		parts := make([]UploadPart, 0, len(completed))
+		for n, etag := range completed {
+			parts = append(parts, UploadPart{PartNumber: n, ETag: etag})
+		}
+		sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+		return &MultipartUploadError{FileID: presigned.FileID, UploadID: presigned.UploadID, CompletedParts: parts, Err: err}
+	}
+
+	for n, partURL := range presigned.PartURLs {
+		num := n + 1
+		if _, ok := completed[num]; ok {
+			continue
+		}
+		start := int64(n) * o.PartSize
+		chunk := data[start : start+partLen(total, o.PartSize, n)]
+		var etag string
+		err := retryStage(ctx, o.MaxAttempts, o.BaseDelay, func() error {
+			var err error
+			etag, err = f.uploadPart(ctx, partURL, presigned.Headers, chunk, o.ContentType, sent, total, o.OnProgress)
+			return err
+		})
+		if err != nil {
+			return nil, fail(fmt.Errorf("part %d upload failed: %w", num, err))
+		}
+		completed[num] = etag
+		sent += int64(len(chunk))
+	}
+
+	var result *UploadResult
+	err = retryStage(ctx, o.MaxAttempts, o.BaseDelay, func() error {
+		var err error
+		result, err = f.confirmMultipart(ctx, presigned.FileID, presigned.UploadID, completed)
+		return err
+	})
+	if err != nil {
+		return nil, fail(fmt.Errorf("confirm failed: %w", err))
+	}
+	return result, nil
+}
+
+// partLen is the byte length of part n (zero-based) of a total-sized
+// upload split into partSize chunks.
+func partLen(total, partSize int64, n int) int64 {
+	start := int64(n) * partSize
+	if remaining := total - start; remaining < partSize {
+		return remaining
+	}
+	return partSize
+}
+
+// presignMultipart asks the server for a multipart upload session, or
+// for fresh part URLs of an existing session when uploadID is set.
+func (f *FilesClient) presignMultipart(ctx context.Context, filename string, size int64, contentType string, partSize int64, uploadID string) (*multipartPresignData, error) {
+	body := map[string]any{
+		"filename":    filename,
+		"size":        size,
+		"contentType": contentType,
+		"partSize":    partSize,
+	}
+	if uploadID != "" {
+		body["uploadId"] = uploadID
+	}
+	res, err := imCall(ctx, f.c, http.MethodPost, "/api/im/files/presign-multipart", nil, body)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var p multipartPresignData
+	if err := res.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to decode presign response: %w", err)
+	}
+	return &p, nil
+}
+
+// uploadPart PUTs one chunk to its presigned URL and returns the ETag
+// the storage backend assigned to it.
+func (f *FilesClient) uploadPart(ctx context.Context, partURL string, headers map[string]string, chunk []byte, contentType string, sentBefore, total int64, progress func(sent, total int64)) (string, error) {
+	var body io.Reader = bytes.NewReader(chunk)
+	if progress != nil {
+		body = &progressReader{r: body, total: total, sent: sentBefore, progress: progress}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, partURL, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to build upload request: %w", err)
+	}
+	req.ContentLength = int64(len(chunk))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", f.c.userAgent)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := f.c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+	return strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
+
+// confirmMultipart finalizes a multipart upload with the collected part
+// ETags and returns the file record.
+func (f *FilesClient) confirmMultipart(ctx context.Context, fileID, uploadID string, completed map[int]string) (*UploadResult, error) {
+	parts := make([]UploadPart, 0, len(completed))
+	for n, etag := range completed {
+		parts = append(parts, UploadPart{PartNumber: n, ETag: etag})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	res, err := imCall(ctx, f.c, http.MethodPost, "/api/im/files/"+fileID+"/confirm-multipart", nil, map[string]any{
+		"uploadId": uploadID,
+		"parts":    parts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	var r UploadResult
+	if err := res.Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode confirm response: %w", err)
+	}
+	if r.FileID == "" {
+		r.FileID = fileID
+	}
+	return &r, nil
+}
+
+// retryStage runs one pipeline stage with exponential backoff, stopping
+// early when ctx is cancelled.
+func retryStage(ctx context.Context, attempts int, baseDelay time.Duration, fn func() error) error {
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(baseDelay << (attempt - 1)):
+			}
+		}
+		if lastErr = fn(); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,224 @@
+package prismer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUploadRetriesConfirmWithoutReuploading(t *testing.T) {
+	var uploads, confirms int
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/im/files/presign":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success": true,
+				"data":    map[string]any{"fileId": "f1", "uploadUrl": srvURL + "/blob/f1"},
+			})
+		case "/blob/f1":
+			uploads++
+			w.WriteHeader(http.StatusOK)
+		case "/api/im/files/f1/confirm":
+			confirms++
+			if confirms == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"success":false,"error":{"code":"UNAVAILABLE","message":"retry"}}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success": true,
+				"data":    map[string]any{"fileId": "f1", "url": "https://files/f1", "size": 3},
+			})
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	res, err := client.IM().Files.Upload(context.Background(), "a.txt", []byte("abc"),
+		&UploadOptions{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if res.FileID != "f1" {
+		t.Fatalf("FileID = %q", res.FileID)
+	}
+	if uploads != 1 {
+		t.Fatalf("bytes uploaded %d times, want 1", uploads)
+	}
+	if confirms != 2 {
+		t.Fatalf("confirm attempted %d times, want 2", confirms)
+	}
+}
+
+func TestUploadReportsIncrementalProgress(t *testing.T) {
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/im/files/presign":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success": true,
+				"data":    map[string]any{"fileId": "f2", "uploadUrl": srvURL + "/blob/f2"},
+			})
+		case "/blob/f2":
+			w.WriteHeader(http.StatusOK)
+		case "/api/im/files/f2/confirm":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success": true,
+				"data":    map[string]any{"fileId": "f2"},
+			})
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	data := make([]byte, 256<<10)
+	var calls []int64
+	client := NewClient("k", WithBaseURL(srv.URL))
+	_, err := client.IM().Files.Upload(context.Background(), "big.bin", data, &UploadOptions{
+		OnProgress: func(sent, total int64) {
+			if total != int64(len(data)) {
+				t.Errorf("total = %d, want %d", total, len(data))
+			}
+			calls = append(calls, sent)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if len(calls) < 2 {
+		t.Fatalf("progress fired %d times, want incremental updates", len(calls))
+	}
+	for i := 1; i < len(calls); i++ {
+		if calls[i] < calls[i-1] {
+			t.Fatalf("progress went backwards: %v", calls)
+		}
+	}
+	if calls[len(calls)-1] != int64(len(data)) {
+		t.Fatalf("final progress = %d, want %d", calls[len(calls)-1], len(data))
+	}
+}
+
+func TestUploadMultipartResumesFromCompletedParts(t *testing.T) {
+	var part2Fails = true
+	var srvURL string
+	var confirmedParts []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/im/files/presign-multipart":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success": true,
+				"data": map[string]any{
+					"fileId":   "f3",
+					"uploadId": "mp-1",
+					"partUrls": []string{srvURL + "/blob/f3/1", srvURL + "/blob/f3/2"},
+				},
+			})
+		case "/blob/f3/1":
+			w.Header().Set("ETag", `"etag-1"`)
+			w.WriteHeader(http.StatusOK)
+		case "/blob/f3/2":
+			if part2Fails {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("ETag", `"etag-2"`)
+			w.WriteHeader(http.StatusOK)
+		case "/api/im/files/f3/confirm-multipart":
+			var body struct {
+				UploadID string           `json:"uploadId"`
+				Parts    []map[string]any `json:"parts"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			confirmedParts = body.Parts
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success": true,
+				"data":    map[string]any{"fileId": "f3", "size": 6},
+			})
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	data := []byte("abcdef")
+	opts := &MultipartOptions{
+		UploadOptions: UploadOptions{MaxAttempts: 2, BaseDelay: time.Millisecond},
+		PartSize:      4,
+	}
+	_, err := client.IM().Files.UploadMultipart(context.Background(), "a.bin", data, opts)
+	var mpErr *MultipartUploadError
+	if !errors.As(err, &mpErr) {
+		t.Fatalf("expected *MultipartUploadError, got %v", err)
+	}
+	if mpErr.UploadID != "mp-1" || len(mpErr.CompletedParts) != 1 || mpErr.CompletedParts[0].ETag != "etag-1" {
+		t.Fatalf("resume state = %+v", mpErr)
+	}
+
+	part2Fails = false
+	opts.UploadID = mpErr.UploadID
+	opts.CompletedParts = mpErr.CompletedParts
+	res, err := client.IM().Files.UploadMultipart(context.Background(), "a.bin", data, opts)
+	if err != nil {
+		t.Fatalf("resumed UploadMultipart: %v", err)
+	}
+	if res.FileID != "f3" {
+		t.Fatalf("FileID = %q", res.FileID)
+	}
+	if len(confirmedParts) != 2 {
+		t.Fatalf("confirm carried %d parts, want 2", len(confirmedParts))
+	}
+	if confirmedParts[0]["etag"] != "etag-1" || confirmedParts[1]["etag"] != "etag-2" {
+		t.Fatalf("confirmed etags = %v", confirmedParts)
+	}
+}
+
+func TestUploadPreflightFailsFastAndCachesLimits(t *testing.T) {
+	var limitsCalls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/im/files/limits" {
+			t.Errorf("preflight should not reach %s", r.URL.Path)
+			return
+		}
+		limitsCalls++
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"data": map[string]any{
+				"maxFileSize":    1024,
+				"remainingQuota": 100,
+				"allowedTypes":   []string{"application/pdf"},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	client := NewClient("k", WithBaseURL(srv.URL))
+	files := client.IM().Files
+
+	_, err := files.Upload(context.Background(), "big.pdf", make([]byte, 512),
+		&UploadOptions{ContentType: "application/pdf", Preflight: true})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	_, err = files.Upload(context.Background(), "a.png", make([]byte, 10),
+		&UploadOptions{ContentType: "image/png", Preflight: true})
+	if !errors.Is(err, ErrTypeNotAllowed) {
+		t.Fatalf("expected ErrTypeNotAllowed, got %v", err)
+	}
+
+	if limitsCalls != 1 {
+		t.Fatalf("limits fetched %d times, want 1 (cached)", limitsCalls)
+	}
+}
@@ -0,0 +1,177 @@
+package prismer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WebhookMessage is the message carried by message.* webhook events.
+type WebhookMessage struct {
+	ID       string          `json:"id"`
+	Content  string          `json:"content"`
+	Type     string          `json:"type,omitempty"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// WebhookSender identifies the user that triggered a webhook event.
+type WebhookSender struct {
+	ID       string `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+// WebhookConversation identifies the conversation a webhook event belongs
+// to.
+type WebhookConversation struct {
+	ID   string `json:"id"`
+	Type string `json:"type,omitempty"`
+}
+
+// WebhookPayload is the event the IM platform POSTs to an agent's webhook
+// endpoint. Message is only present on message.* events; event types the
+// SDK has no dedicated field for carry their specifics in Data.
+type WebhookPayload struct {
+	Event        string              `json:"event"`
+	Message      *WebhookMessage     `json:"message,omitempty"`
+	Sender       WebhookSender       `json:"sender"`
+	Conversation WebhookConversation `json:"conversation"`
+	Data         json.RawMessage     `json:"data,omitempty"`
+}
+
+// WebhookReply is the synchronous answer a webhook handler returns. A nil
+// reply acknowledges the event without responding.
+//
+// Setting Typing (without content) tells the platform to show a typing
+// indicator in the conversation: a handler that needs time can reply with
+// {Typing: true} immediately and deliver the real message later through the
+// regular send API. Content and Typing may be combined to post a quick
+// "thinking" message while keeping the indicator on.
+type WebhookReply struct {
+	Content  string         `json:"content,omitempty"`
+	Type     string         `json:"type,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Typing   bool           `json:"typing,omitempty"`
+}
+
+// WebhookHandlerFunc handles one webhook event.
+type WebhookHandlerFunc func(*WebhookPayload) (*WebhookReply, error)
+
+// WebhookContext carries a webhook event together with the raw request
+// body and headers, for handlers that need to re-sign, forward, or pull
+// correlation IDs out of the original request.
+type WebhookContext struct {
+	Payload *WebhookPayload
+	RawBody []byte
+	Headers http.Header
+}
+
+// WebhookContextHandlerFunc handles one webhook event with full request
+// context. Handlers that only need the payload can keep using
+// WebhookHandlerFunc.
+type WebhookContextHandlerFunc func(*WebhookContext) (*WebhookReply, error)
+
+// ParseWebhookPayload decodes and validates a webhook request body. What
+// is required depends on the event type: message.* events must carry a
+// message and conversation, conversation.* and participant.* events a
+// conversation; other event types only need a non-empty event name.
+func ParseWebhookPayload(body []byte) (*WebhookPayload, error) {
+	var p WebhookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if p.Event == "" {
+		return nil, errors.New("webhook payload missing event")
+	}
+	switch {
+	case strings.HasPrefix(p.Event, "message."):
+		if p.Message == nil || p.Message.ID == "" {
+			return nil, errors.New("webhook payload missing message.id")
+		}
+		if p.Conversation.ID == "" {
+			return nil, errors.New("webhook payload missing conversation.id")
+		}
+		if p.Event == "message.new" && p.Sender.ID == "" {
+			return nil, errors.New("webhook payload missing sender.id")
+		}
+	case strings.HasPrefix(p.Event, "conversation."), strings.HasPrefix(p.Event, "participant."):
+		if p.Conversation.ID == "" {
+			return nil, errors.New("webhook payload missing conversation.id")
+		}
+	}
+	return &p, nil
+}
+
+// PrismerWebhook is an http.Handler that receives IM webhook events and
+// dispatches them to per-event handlers. message.new goes to the handler
+// given at construction; other events go to handlers registered with
+// OnEvent.
+type PrismerWebhook struct {
+	onMessage    WebhookHandlerFunc
+	onMessageCtx WebhookContextHandlerFunc
+	handlers     map[string]WebhookHandlerFunc
+}
+
+// NewPrismerWebhook builds a webhook handler around onMessage.
+func NewPrismerWebhook(onMessage WebhookHandlerFunc) *PrismerWebhook {
+	return &PrismerWebhook{onMessage: onMessage}
+}
+
+// NewPrismerWebhookContext builds a webhook handler whose handler also
+// receives the raw request body and headers.
+func NewPrismerWebhookContext(onMessage WebhookContextHandlerFunc) *PrismerWebhook {
+	return &PrismerWebhook{onMessageCtx: onMessage}
+}
+
+// OnEvent registers a handler for an event type (e.g. "conversation.create"
+// or "participant.add"), replacing any previous handler for that type.
+// For "message.new" it overrides the constructor handler.
+func (h *PrismerWebhook) OnEvent(eventType string, handler WebhookHandlerFunc) {
+	if h.handlers == nil {
+		h.handlers = make(map[string]WebhookHandlerFunc)
+	}
+	h.handlers[eventType] = handler
+}
+
+// ServeHTTP implements http.Handler.
+func (h *PrismerWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	payload, err := ParseWebhookPayload(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var reply *WebhookReply
+	switch {
+	case h.handlers[payload.Event] != nil:
+		reply, err = h.handlers[payload.Event](payload)
+	case payload.Event == "message.new" && h.onMessageCtx != nil:
+		reply, err = h.onMessageCtx(&WebhookContext{Payload: payload, RawBody: body, Headers: r.Header})
+	case payload.Event == "message.new" && h.onMessage != nil:
+		reply, err = h.onMessage(payload)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if reply == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(reply)
+}
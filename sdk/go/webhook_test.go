@@ -0,0 +1,88 @@
+package prismer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookDispatchesPerEventHandlers(t *testing.T) {
+	wh := NewPrismerWebhook(func(p *WebhookPayload) (*WebhookReply, error) {
+		return &WebhookReply{Content: "got " + p.Message.Content}, nil
+	})
+	var joined string
+	wh.OnEvent("participant.add", func(p *WebhookPayload) (*WebhookReply, error) {
+		joined = p.Sender.ID
+		return nil, nil
+	})
+
+	srv := httptest.NewServer(wh)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(
+		`{"event":"participant.add","sender":{"id":"u9"},"conversation":{"id":"c1"}}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("participant.add status = %d, want 204", resp.StatusCode)
+	}
+	if joined != "u9" {
+		t.Fatalf("participant handler saw sender %q, want u9", joined)
+	}
+
+	resp, err = http.Post(srv.URL, "application/json", strings.NewReader(
+		`{"event":"message.new","message":{"id":"m1","content":"hi"},"sender":{"id":"u1"},"conversation":{"id":"c1"}}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("message.new status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestParseWebhookPayloadValidatesByEventType(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{
+			name: "message.new requires message",
+			body: `{"event":"message.new","sender":{"id":"u1"},"conversation":{"id":"c1"}}`,
+
+			wantErr: true,
+		},
+		{
+			name:    "conversation.create without message is fine",
+			body:    `{"event":"conversation.create","conversation":{"id":"c1"}}`,
+			wantErr: false,
+		},
+		{
+			name:    "participant.add requires conversation",
+			body:    `{"event":"participant.add","sender":{"id":"u1"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown event passes through",
+			body:    `{"event":"credits.low","data":{"balance":3}}`,
+			wantErr: false,
+		},
+		{
+			name:    "missing event rejected",
+			body:    `{"conversation":{"id":"c1"}}`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseWebhookPayload([]byte(tc.body))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr = %t", err, tc.wantErr)
+			}
+		})
+	}
+}